@@ -0,0 +1,128 @@
+package soup
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// ErrLoginFailed is returned by LoginWithForm if the page after the login doesn't show the success element
+var ErrLoginFailed = errors.New("login failed")
+
+// Credentials is a header authenticating the requests to a host, like an API key header.
+// BasicAuth and BearerToken create the common Authorization headers.
+type Credentials struct {
+	Header string
+	Value  string
+}
+
+// BasicAuth returns the credentials of HTTP Basic authentication
+func BasicAuth(username string, password string) Credentials {
+	return Credentials{"Authorization", "Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+password))}
+}
+
+// BearerToken returns the credentials of a bearer token
+func BearerToken(token string) Credentials {
+	return Credentials{"Authorization", "Bearer " + token}
+}
+
+// credentialsTransport adds the credentials of the host to every request, so they aren't sent to other hosts after redirects
+type credentialsTransport struct {
+	transport   http.RoundTripper
+	credentials map[string]Credentials
+}
+
+// returns the credentials of the host, preferring those given with the port
+func credentialsFor(credentials map[string]Credentials, host string) (Credentials, bool) {
+	host = strings.ToLower(host)
+	if c, ok := credentials[host]; ok {
+		return c, true
+	}
+	for name, c := range credentials {
+		if strings.ToLower(name) == host || strings.ToLower(name) == canonicalHost(host) {
+			return c, true
+		}
+	}
+	return Credentials{}, false
+}
+
+// RoundTrip sends the request with the credentials of its host
+func (t *credentialsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	transport := t.transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	c, ok := credentialsFor(t.credentials, req.URL.Host)
+	if !ok {
+		return transport.RoundTrip(req)
+	}
+	// a RoundTripper mustn't modify the request
+	authenticated := req.Clone(req.Context())
+	authenticated.Header.Set(c.Header, c.Value)
+	return transport.RoundTrip(authenticated)
+}
+
+// LoginWithForm fetches the login page and submits its form after setting the values,
+// keeping the default values like hidden CSRF tokens.
+// formSelector selects the login form, if it is empty the first form with a password field is used.
+// The login succeeded if the page returned by the submission contains an element matching successSelector,
+// otherwise ErrLoginFailed is returned together with the response.
+func (s *Session) LoginWithForm(pageURL string, formSelector string, values map[string]string, successSelector string) (*Response, error) {
+	success, err := compileSelector(successSelector)
+	if err != nil {
+		if debug {
+			panic("Invalid selector " + successSelector)
+		}
+		return nil, errors.New("invalid selector " + successSelector)
+	}
+	page, err := s.Do("GET", pageURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	doc := page.HTML()
+	forms := doc.Forms()
+	var login *Form
+	if formSelector != "" {
+		sel, err := compileSelector(formSelector)
+		if err != nil {
+			if debug {
+				panic("Invalid selector " + formSelector)
+			}
+			return nil, errors.New("invalid selector " + formSelector)
+		}
+		if element, ok := doc.selectOnce(sel); ok {
+			for position := range forms {
+				if forms[position].Element.Pointer == element.Pointer {
+					login = &forms[position]
+					break
+				}
+			}
+		}
+	} else {
+		passwordField, _ := compileSelector(`input[type="password"]`)
+		for position := range forms {
+			if _, ok := forms[position].Element.selectOnce(passwordField); ok {
+				login = &forms[position]
+				break
+			}
+		}
+	}
+	if login == nil {
+		if debug {
+			panic("Login form not found on " + pageURL)
+		}
+		return nil, errors.New("login form not found on " + pageURL)
+	}
+	for name, value := range values {
+		login.Set(name, value)
+	}
+	resp, err := s.Submit(*login)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := resp.HTML().selectOnce(success); !ok {
+		return resp, ErrLoginFailed
+	}
+	return resp, nil
+}
@@ -0,0 +1,80 @@
+package soup
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCredentials(t *testing.T) {
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("Authorization") + "|" + r.Header.Get("X-Api-Key")))
+	}))
+	defer other.Close()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, other.URL, http.StatusFound)
+			return
+		}
+		username, password, _ := r.BasicAuth()
+		w.Write([]byte(username + ":" + password))
+	}))
+	defer ts.Close()
+	host := mustParseURL(ts.URL).Host
+	otherHost := mustParseURL(other.URL).Host
+
+	session := NewSession()
+	session.Credentials = map[string]Credentials{host: BasicAuth("user", "secret")}
+	if body, err := session.Get(ts.URL); err != nil || body != "user:secret" {
+		t.Errorf("Basic auth not sent, got %q (%v)", body, err)
+	}
+	if body, err := session.Get(ts.URL + "/redirect"); err != nil || body != "|" {
+		t.Errorf("Credentials leaked to another host after a redirect, got %q (%v)", body, err)
+	}
+	session.Credentials[otherHost] = Credentials{"X-Api-Key", "key"}
+	if body, err := session.Get(other.URL); err != nil || body != "|key" {
+		t.Errorf("Custom header not sent, got %q (%v)", body, err)
+	}
+	session.Credentials = map[string]Credentials{"127.0.0.1": BearerToken("token")}
+	if body, err := session.Get(other.URL); err != nil || body != "Bearer token|" {
+		t.Errorf("Bearer token not sent to the host without port, got %q (%v)", body, err)
+	}
+}
+
+func TestLoginWithForm(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			http.SetCookie(w, &http.Cookie{Name: "csrf", Value: "token123"})
+			w.Write([]byte(`<html><body>
+				<form action="/search"><input name="q"></form>
+				<form method="post" action="/session">
+					<input type="hidden" name="csrf" value="token123">
+					<input name="username"><input type="password" name="password">
+				</form></body></html>`))
+		case "/session":
+			r.ParseForm()
+			csrf, err := r.Cookie("csrf")
+			if err != nil || csrf.Value != r.PostForm.Get("csrf") || r.PostForm.Get("password") != "secret" {
+				w.Write([]byte(`<p class="error">Wrong credentials</p>`))
+				return
+			}
+			w.Write([]byte(`<a id="logout" href="/logout">Log out ` + r.PostForm.Get("username") + `</a>`))
+		}
+	}))
+	defer ts.Close()
+
+	session := NewSession()
+	resp, err := session.LoginWithForm(ts.URL+"/login", "", map[string]string{"username": "anas", "password": "secret"}, "#logout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.HTML().Find("a").Text() != "Log out anas" {
+		t.Errorf("Unexpected page after login: %q", resp.Body)
+	}
+	_, err = session.LoginWithForm(ts.URL+"/login", `form[action="/session"]`, map[string]string{"password": "wrong"}, "#logout")
+	if !errors.Is(err, ErrLoginFailed) {
+		t.Errorf("Expected ErrLoginFailed, got %v", err)
+	}
+}
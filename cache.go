@@ -0,0 +1,231 @@
+package soup
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNotCached is returned for requests which are not cached while the session forces the use of the cache
+var ErrNotCached = errors.New("response is not cached")
+
+// CachedResponse is a response stored in a cache
+type CachedResponse struct {
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header"`
+	URL        string      `json:"url"`
+	Body       string      `json:"body"`
+	Stored     time.Time   `json:"stored"`
+}
+
+// Cache stores the responses of a session
+type Cache interface {
+	Get(key string) (*CachedResponse, bool)
+	Set(key string, resp *CachedResponse)
+	Delete(key string)
+}
+
+// MemoryCache keeps a limited number of responses in memory, dropping the least recently used ones
+type MemoryCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	entries    map[string]*list.Element
+	order      *list.List
+}
+
+// memoryCacheEntry is an element of the LRU list
+type memoryCacheEntry struct {
+	key  string
+	resp *CachedResponse
+}
+
+// NewMemoryCache creates a cache holding up to maxEntries responses, 0 means unlimited
+func NewMemoryCache(maxEntries int) *MemoryCache {
+	return &MemoryCache{
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+	}
+}
+
+// Get returns the response stored for the key
+func (c *MemoryCache) Get(key string) (*CachedResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+	return element.Value.(*memoryCacheEntry).resp, true
+}
+
+// Set stores the response for the key
+func (c *MemoryCache) Set(key string, resp *CachedResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[key]; ok {
+		element.Value.(*memoryCacheEntry).resp = resp
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&memoryCacheEntry{key, resp})
+	if c.maxEntries > 0 && c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*memoryCacheEntry).key)
+	}
+}
+
+// Delete removes the response stored for the key
+func (c *MemoryCache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[key]; ok {
+		c.order.Remove(element)
+		delete(c.entries, key)
+	}
+}
+
+// DiskCache stores every response as JSON file in a directory, surviving restarts
+type DiskCache struct {
+	dir string
+}
+
+// NewDiskCache creates a cache storing the responses in the directory, which is created if needed
+func NewDiskCache(dir string) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &DiskCache{dir}, nil
+}
+
+// returns the file of the key
+func (c *DiskCache) path(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(hash[:])+".json")
+}
+
+// Get returns the response stored for the key
+func (c *DiskCache) Get(key string) (*CachedResponse, bool) {
+	content, err := ioutil.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+	var resp CachedResponse
+	if err := json.Unmarshal(content, &resp); err != nil {
+		return nil, false
+	}
+	return &resp, true
+}
+
+// Set stores the response for the key
+func (c *DiskCache) Set(key string, resp *CachedResponse) {
+	content, err := json.Marshal(resp)
+	if err != nil {
+		return
+	}
+	file, err := ioutil.TempFile(c.dir, "tmp-")
+	if err != nil {
+		return
+	}
+	_, err = file.Write(content)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return
+	}
+	if err := os.Rename(file.Name(), c.path(key)); err != nil {
+		os.Remove(file.Name())
+	}
+}
+
+// Delete removes the response stored for the key
+func (c *DiskCache) Delete(key string) {
+	os.Remove(c.path(key))
+}
+
+// returns the Cache-Control directives of the header in lowercase
+func cacheControl(header http.Header) map[string]string {
+	directives := make(map[string]string)
+	for _, line := range header["Cache-Control"] {
+		for _, directive := range strings.Split(line, ",") {
+			directive = strings.TrimSpace(directive)
+			if directive == "" {
+				continue
+			}
+			name, value := directive, ""
+			if equals := strings.IndexByte(directive, '='); equals != -1 {
+				name, value = directive[:equals], strings.Trim(directive[equals+1:], `"`)
+			}
+			directives[strings.ToLower(name)] = value
+		}
+	}
+	return directives
+}
+
+// checks if the response may be stored
+func cacheable(resp *Response) bool {
+	if resp.StatusCode != http.StatusOK {
+		return false
+	}
+	_, noStore := cacheControl(resp.Header)["no-store"]
+	return !noStore
+}
+
+// checks if the cached response may be used without revalidating it
+func (c *CachedResponse) fresh(now time.Time) bool {
+	directives := cacheControl(c.Header)
+	if _, noCache := directives["no-cache"]; noCache {
+		return false
+	}
+	age := now.Sub(c.Stored)
+	if maxAge, ok := directives["max-age"]; ok {
+		seconds, err := strconv.Atoi(maxAge)
+		return err == nil && age < time.Duration(seconds)*time.Second
+	}
+	if expires := c.Header.Get("Expires"); expires != "" {
+		expiry, err := http.ParseTime(expires)
+		if err != nil {
+			return false
+		}
+		date, err := http.ParseTime(c.Header.Get("Date"))
+		if err != nil {
+			date = c.Stored
+		}
+		return age < expiry.Sub(date)
+	}
+	return false
+}
+
+// returns the headers revalidating the cached response, nil if it has no validators
+func (c *CachedResponse) conditionalHeader() http.Header {
+	header := make(http.Header)
+	if etag := c.Header.Get("ETag"); etag != "" {
+		header.Set("If-None-Match", etag)
+	}
+	if lastModified := c.Header.Get("Last-Modified"); lastModified != "" {
+		header.Set("If-Modified-Since", lastModified)
+	}
+	if len(header) == 0 {
+		return nil
+	}
+	return header
+}
+
+// returns the response of the cache entry
+func (c *CachedResponse) response() *Response {
+	return &Response{StatusCode: c.StatusCode, Header: c.Header, URL: c.URL, Body: c.Body, Cached: true}
+}
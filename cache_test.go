@@ -0,0 +1,108 @@
+package soup
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMemoryCacheEviction(t *testing.T) {
+	cache := NewMemoryCache(2)
+	cache.Set("a", &CachedResponse{Body: "a"})
+	cache.Set("b", &CachedResponse{Body: "b"})
+	cache.Get("a")
+	cache.Set("c", &CachedResponse{Body: "c"})
+	if _, ok := cache.Get("b"); ok {
+		t.Errorf("Least recently used entry should be evicted")
+	}
+	if resp, ok := cache.Get("a"); !ok || resp.Body != "a" {
+		t.Errorf("Recently used entry should be kept")
+	}
+	cache.Delete("a")
+	if _, ok := cache.Get("a"); ok {
+		t.Errorf("Deleted entry should be removed")
+	}
+}
+
+func TestDiskCache(t *testing.T) {
+	dir, err := os.MkdirTemp("", "soup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cache, err := NewDiskCache(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored := time.Now().Truncate(time.Second)
+	cache.Set("GET https://example.com/", &CachedResponse{200, http.Header{"Etag": {`"1"`}}, "https://example.com/", "body", stored})
+	resp, ok := cache.Get("GET https://example.com/")
+	if !ok || resp.Body != "body" || resp.Header.Get("ETag") != `"1"` || !resp.Stored.Equal(stored) {
+		t.Errorf("Wrong cached response: %+v", resp)
+	}
+	cache.Delete("GET https://example.com/")
+	if _, ok := cache.Get("GET https://example.com/"); ok {
+		t.Errorf("Deleted entry should be removed")
+	}
+}
+
+func TestSessionCache(t *testing.T) {
+	var requests, revalidations int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		switch r.URL.Path {
+		case "/fresh":
+			w.Header().Set("Cache-Control", "max-age=60")
+			w.Write([]byte("fresh"))
+		case "/etag":
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				atomic.AddInt32(&revalidations, 1)
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", `"v1"`)
+			w.Write([]byte("etag"))
+		case "/no-store":
+			w.Header().Set("Cache-Control", "no-store")
+			w.Write([]byte("no-store"))
+		}
+	}))
+	defer ts.Close()
+
+	session := NewSession()
+	session.Cache = NewMemoryCache(10)
+	for i := 0; i < 2; i++ {
+		if actual, _ := session.Get(ts.URL + "/fresh"); actual != "fresh" {
+			t.Errorf("Wrong body: %s", actual)
+		}
+	}
+	if atomic.LoadInt32(&requests) != 1 {
+		t.Errorf("Fresh response should be answered from the cache")
+	}
+
+	atomic.StoreInt32(&requests, 0)
+	session.Get(ts.URL + "/etag")
+	resp, err := session.Do("GET", ts.URL+"/etag", nil)
+	if err != nil || resp.Body != "etag" || !resp.Cached || atomic.LoadInt32(&revalidations) != 1 {
+		t.Errorf("Stale response should be revalidated: %+v (%v)", resp, err)
+	}
+
+	atomic.StoreInt32(&requests, 0)
+	session.Get(ts.URL + "/no-store")
+	session.Get(ts.URL + "/no-store")
+	if atomic.LoadInt32(&requests) != 2 {
+		t.Errorf("no-store responses should not be cached")
+	}
+
+	atomic.StoreInt32(&requests, 0)
+	session.ForceCache = true
+	if actual, _ := session.Get(ts.URL + "/etag"); actual != "etag" || atomic.LoadInt32(&requests) != 0 {
+		t.Errorf("Forced cache should not hit the network")
+	}
+	if _, err := session.Get(ts.URL + "/missing"); err != ErrNotCached {
+		t.Errorf("Missing entry should return ErrNotCached, got %v", err)
+	}
+}
@@ -0,0 +1,258 @@
+package soup
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Crawler visits the pages reachable from start URLs with several workers, following their links
+// breadth-first and passing every page to a callback. The requests are sent by the Session,
+// so its rate limits, robots.txt policy, retries and cache apply to the crawl.
+type Crawler struct {
+	// Session sends the requests, a new session is used if it is nil
+	Session *Session
+	// Workers is the number of pages fetched concurrently, at least 1
+	Workers int
+	// MaxDepth limits the number of links followed from the start URLs, 0 means unlimited
+	MaxDepth int
+	// MaxPages stops the crawl after fetching the number of pages, 0 means unlimited
+	MaxPages int
+	// AllowedDomains restricts the crawl to the domains and their subdomains, all domains are allowed if it is empty
+	AllowedDomains []string
+	// DeniedDomains excludes the domains and their subdomains from the crawl
+	DeniedDomains []string
+	// Follow filters the links followed, only links to HTTP(S) pages are followed in any case
+	Follow LinkOptions
+	// OnPage is called for every fetched HTML page, concurrently by the workers
+	OnPage func(page *Page)
+	// OnError is called for every URL which couldn't be fetched, returned an error or redirect status
+	// or isn't an HTML page, concurrently by the workers
+	OnError func(url string, err error)
+}
+
+// Page is a page fetched by a Crawler
+type Page struct {
+	// URL is the normalized URL of the page, Response.URL the URL it was finally fetched from
+	URL      string
+	Depth    int
+	Response *Response
+	// Root is the parsed HTML of the page, Links the links found in it
+	Root  Root
+	Links []Link
+}
+
+// crawlTask is an URL waiting in the frontier
+type crawlTask struct {
+	url   string
+	depth int
+}
+
+// crawl is the state of a single run of a crawler
+type crawl struct {
+	*Crawler
+	session *Session
+	ctx     context.Context
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	queue   []crawlTask
+	seen    map[string]bool
+	pending int
+	pages   int
+}
+
+// Run crawls from the start URLs until there are no more pages to fetch or the context is cancelled.
+// Cancelling the context aborts the requests in flight, without reporting them to OnError, and returns the error of the context.
+func (c *Crawler) Run(ctx context.Context, startURLs ...string) error {
+	state := &crawl{Crawler: c, session: c.Session, ctx: ctx, seen: make(map[string]bool)}
+	if state.session == nil {
+		state.session = NewSession()
+	}
+	state.cond = sync.NewCond(&state.mu)
+	for _, start := range startURLs {
+		target, err := url.Parse(start)
+		if err != nil || !target.IsAbs() {
+			c.reportError(start, errors.New("unable to parse the url "+start))
+			continue
+		}
+		state.enqueue(target, 0)
+	}
+	stop := context.AfterFunc(ctx, func() {
+		state.mu.Lock()
+		state.cond.Broadcast()
+		state.mu.Unlock()
+	})
+	defer stop()
+
+	workers := c.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			state.work()
+		}()
+	}
+	wg.Wait()
+	return ctx.Err()
+}
+
+// calls OnError if it is set
+func (c *Crawler) reportError(url string, err error) {
+	if c.OnError != nil {
+		c.OnError(url, err)
+	}
+}
+
+// checks if the crawler may visit the host
+func (c *Crawler) allowed(host string) bool {
+	host = canonicalHost(host)
+	matches := func(domains []string) bool {
+		for _, domain := range domains {
+			domain = canonicalHost(domain)
+			if host == domain || strings.HasSuffix(host, "."+domain) {
+				return true
+			}
+		}
+		return false
+	}
+	if matches(c.DeniedDomains) {
+		return false
+	}
+	return len(c.AllowedDomains) == 0 || matches(c.AllowedDomains)
+}
+
+// adds the URL to the frontier unless it was seen before or isn't allowed
+func (s *crawl) enqueue(target *url.URL, depth int) {
+	if (target.Scheme != "http" && target.Scheme != "https") || !s.allowed(target.Host) {
+		return
+	}
+	normalized := normalizeURL(target)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.seen[normalized] {
+		return
+	}
+	s.seen[normalized] = true
+	s.queue = append(s.queue, crawlTask{normalized, depth})
+	s.pending++
+	s.cond.Signal()
+}
+
+// takes the next task from the frontier, false if the crawl is finished
+func (s *crawl) next() (crawlTask, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for {
+		if s.ctx.Err() != nil || (s.MaxPages > 0 && s.pages >= s.MaxPages) {
+			return crawlTask{}, false
+		}
+		if len(s.queue) > 0 {
+			task := s.queue[0]
+			s.queue = s.queue[1:]
+			s.pages++
+			return task, true
+		}
+		if s.pending == 0 {
+			return crawlTask{}, false
+		}
+		s.cond.Wait()
+	}
+}
+
+// marks a task as finished, waking up the idle workers once nothing is left to do
+func (s *crawl) done() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pending--
+	if s.pending == 0 || (s.MaxPages > 0 && s.pages >= s.MaxPages) {
+		s.cond.Broadcast()
+	}
+}
+
+// fetches pages until the crawl is finished
+func (s *crawl) work() {
+	for {
+		task, ok := s.next()
+		if !ok {
+			return
+		}
+		s.visit(task)
+		s.done()
+	}
+}
+
+// fetches the page of the task, passes it to the callback and adds its links to the frontier
+func (s *crawl) visit(task crawlTask) {
+	resp, err := s.session.DoContext(s.ctx, "GET", task.url, nil)
+	if err != nil {
+		if s.ctx.Err() == nil {
+			s.reportError(task.url, err)
+		}
+		return
+	}
+	page := &Page{URL: task.url, Depth: task.depth, Response: resp}
+	final, err := url.Parse(resp.URL)
+	if err == nil && final.String() != task.url {
+		// pages reached through redirects aren't crawled twice
+		normalized := normalizeURL(final)
+		s.mu.Lock()
+		s.seen[normalized] = true
+		s.mu.Unlock()
+	}
+	contentType := resp.Header.Get("Content-Type")
+	switch {
+	case resp.StatusCode >= 300:
+		s.reportError(task.url, errors.New("page "+task.url+" returned status "+strconv.Itoa(resp.StatusCode)))
+		return
+	case contentType != "" && !strings.Contains(contentType, "html"):
+		s.reportError(task.url, errors.New("page "+task.url+" isn't HTML but "+contentType))
+		return
+	}
+	page.Root = resp.HTML()
+	if page.Root.Error != nil {
+		s.reportError(task.url, page.Root.Error)
+		return
+	}
+	page.Links = page.Root.ExtractLinks(s.Follow)
+	if s.OnPage != nil {
+		s.OnPage(page)
+	}
+	if s.MaxDepth > 0 && task.depth >= s.MaxDepth {
+		return
+	}
+	for _, link := range page.Links {
+		if link.Kind == PageLink && link.URL.IsAbs() {
+			s.enqueue(link.URL, task.depth+1)
+		}
+	}
+}
+
+// normalizes the URL for deduplication: the scheme and host are lowercased,
+// default ports, fragments and empty queries are dropped and the query parameters are sorted
+func normalizeURL(target *url.URL) string {
+	normalized := *target
+	normalized.Scheme = strings.ToLower(normalized.Scheme)
+	normalized.Host = strings.ToLower(normalized.Host)
+	if (normalized.Scheme == "http" && strings.HasSuffix(normalized.Host, ":80")) ||
+		(normalized.Scheme == "https" && strings.HasSuffix(normalized.Host, ":443")) {
+		normalized.Host = normalized.Host[:strings.LastIndexByte(normalized.Host, ':')]
+	}
+	normalized.Fragment = ""
+	normalized.RawFragment = ""
+	if normalized.Path == "" {
+		normalized.Path = "/"
+	}
+	if query, err := url.ParseQuery(normalized.RawQuery); err == nil {
+		normalized.RawQuery = query.Encode()
+	}
+	normalized.ForceQuery = false
+	return normalized.String()
+}
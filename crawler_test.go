@@ -0,0 +1,217 @@
+package soup
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// crawlServer serves a site whose page n links to the pages 2n and 2n+1, up to page 15
+func crawlServer(t *testing.T, requests *sync.Map) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if previous, loaded := requests.LoadOrStore(r.URL.String(), true); loaded && previous.(bool) {
+			t.Errorf("Page %s requested twice", r.URL)
+		}
+		var n int
+		fmt.Sscanf(r.URL.Path, "/page/%d", &n)
+		if n == 0 {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, `<html><body><h1>Page %d</h1>`, n)
+		for _, child := range []int{2 * n, 2*n + 1} {
+			if child <= 15 {
+				// links differing by fragment, query order and case of the host are the same page
+				fmt.Fprintf(w, `<a href="/page/%d#top">%d</a> <a href="http://LOCALHOST:%s/page/%d?b=2&a=1">%d</a>`, child, child, r.Host[len("127.0.0.1:"):], child, child)
+			}
+		}
+		fmt.Fprint(w, `<a href="https://example.com/">External</a> <a href="mailto:a@example.com">Mail</a></body></html>`)
+	}))
+}
+
+func TestCrawler(t *testing.T) {
+	var requests sync.Map
+	ts := crawlServer(t, &requests)
+	defer ts.Close()
+	start := "http://localhost:" + ts.URL[len("http://127.0.0.1:"):] + "/page/1"
+
+	var mu sync.Mutex
+	depths := make(map[string]int)
+	crawler := &Crawler{
+		Workers:        4,
+		MaxDepth:       2,
+		AllowedDomains: []string{"localhost"},
+		OnPage: func(page *Page) {
+			mu.Lock()
+			defer mu.Unlock()
+			depths[page.Root.Find("h1").Text()] = page.Depth
+		},
+	}
+	if err := crawler.Run(context.Background(), start); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]int{"Page 1": 0, "Page 2": 1, "Page 3": 1, "Page 4": 2, "Page 5": 2, "Page 6": 2, "Page 7": 2}
+	if fmt.Sprint(depths) != fmt.Sprint(expected) {
+		t.Errorf("Expected pages %v, got %v", expected, depths)
+	}
+}
+
+func TestCrawlerDeniedDomainsAndErrors(t *testing.T) {
+	var requests sync.Map
+	ts := crawlServer(t, &requests)
+	defer ts.Close()
+
+	var failed []string
+	var mu sync.Mutex
+	pages := 0
+	crawler := &Crawler{
+		AllowedDomains: []string{"127.0.0.1"},
+		DeniedDomains:  []string{"localhost"},
+		OnPage: func(page *Page) {
+			mu.Lock()
+			pages++
+			mu.Unlock()
+		},
+		OnError: func(url string, err error) {
+			mu.Lock()
+			failed = append(failed, url)
+			mu.Unlock()
+		},
+	}
+	if err := crawler.Run(context.Background(), ts.URL+"/page/8", "http://127.0.0.1:1/", "not a url"); err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(failed)
+	if pages != 1 || len(failed) != 2 || failed[0] != "http://127.0.0.1:1/" {
+		t.Errorf("Expected one page and two errors, got %d pages and errors for %v", pages, failed)
+	}
+}
+
+func TestCrawlerErrorPages(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			fmt.Fprint(w, `<html><body><h1>Start</h1><a href="/missing">Missing</a> <a href="/report.pdf">Report</a></body></html>`)
+		case "/report.pdf":
+			w.Header().Set("Content-Type", "application/pdf")
+			fmt.Fprint(w, "%PDF-1.4")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	var mu sync.Mutex
+	var titles, failed []string
+	crawler := &Crawler{
+		OnPage: func(page *Page) {
+			mu.Lock()
+			defer mu.Unlock()
+			titles = append(titles, page.Root.Find("h1").Text())
+		},
+		OnError: func(url string, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			failed = append(failed, url+" "+err.Error())
+		},
+	}
+	if err := crawler.Run(context.Background(), ts.URL+"/"); err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(failed)
+	expected := []string{
+		ts.URL + "/missing page " + ts.URL + "/missing returned status 404",
+		ts.URL + "/report.pdf page " + ts.URL + "/report.pdf isn't HTML but application/pdf",
+	}
+	if fmt.Sprint(titles) != "[Start]" || fmt.Sprint(failed) != fmt.Sprint(expected) {
+		t.Errorf("Expected the start page and errors %v, got %v and %v", expected, titles, failed)
+	}
+}
+
+func TestCrawlerCancel(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(5 * time.Millisecond)
+		// every page links to two new pages, so the crawl never ends by itself
+		fmt.Fprintf(w, `<a href="%sa/">a</a><a href="%sb/">b</a>`, r.URL.Path, r.URL.Path)
+	}))
+	defer ts.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var mu sync.Mutex
+	pages := 0
+	var errs []error
+	crawler := &Crawler{
+		Workers: 3,
+		OnError: func(url string, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			errs = append(errs, err)
+		},
+		OnPage: func(page *Page) {
+			mu.Lock()
+			defer mu.Unlock()
+			pages++
+			if pages == 10 {
+				cancel()
+			}
+		},
+	}
+	done := make(chan error)
+	go func() {
+		done <- crawler.Run(ctx, ts.URL)
+	}()
+	select {
+	case err := <-done:
+		if err != context.Canceled {
+			t.Errorf("Expected context.Canceled, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Crawler didn't stop after the context was cancelled")
+	}
+	if pages > 13 {
+		t.Errorf("At most the pages in flight should be finished after cancelling, got %d pages", pages)
+	}
+	if len(errs) > 0 {
+		t.Errorf("Aborted requests should not be reported: %v", errs)
+	}
+}
+
+func TestCrawlerMaxPages(t *testing.T) {
+	var requests sync.Map
+	ts := crawlServer(t, &requests)
+	defer ts.Close()
+
+	crawler := &Crawler{Workers: 2, MaxPages: 5, AllowedDomains: []string{"127.0.0.1"}}
+	if err := crawler.Run(context.Background(), ts.URL+"/page/1"); err != nil {
+		t.Fatal(err)
+	}
+	count := 0
+	requests.Range(func(key, value interface{}) bool {
+		count++
+		return true
+	})
+	if count != 5 {
+		t.Errorf("Expected 5 pages to be fetched, got %d", count)
+	}
+}
+
+func TestNormalizeURL(t *testing.T) {
+	tests := map[string]string{
+		"HTTP://Example.COM:80":               "http://example.com/",
+		"https://example.com:443/a?b=2&a=1#x": "https://example.com/a?a=1&b=2",
+		"https://example.com:8443/a?":         "https://example.com:8443/a",
+	}
+	for input, expected := range tests {
+		target, _ := url.Parse(input)
+		if normalized := normalizeURL(target); normalized != expected {
+			t.Errorf("%s: expected %s, got %s", input, expected, normalized)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package soup
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// acceptEncoding lists the content codings decoded by the sessions
+const acceptEncoding = "gzip, deflate, br, zstd"
+
+// BodyTooLargeError is returned for responses whose decoded body exceeds the maximum body size
+type BodyTooLargeError struct {
+	URL   string
+	Limit int64
+}
+
+func (e *BodyTooLargeError) Error() string {
+	return "response body of " + e.URL + " exceeds the limit of " + strconv.FormatInt(e.Limit, 10) + " bytes"
+}
+
+// returns the content codings of the header in the order they were applied,
+// false if one of them can't be decoded
+func contentCodings(header http.Header) ([]string, bool) {
+	var codings []string
+	for _, line := range header["Content-Encoding"] {
+		for _, coding := range strings.Split(line, ",") {
+			coding = strings.ToLower(strings.TrimSpace(coding))
+			switch coding {
+			case "", "identity":
+			case "gzip", "x-gzip", "deflate", "br", "zstd":
+				codings = append(codings, coding)
+			default:
+				return nil, false
+			}
+		}
+	}
+	return codings, true
+}
+
+// returns a reader decoding the body with the content coding
+func decoder(coding string, body io.Reader) (io.ReadCloser, error) {
+	switch coding {
+	case "gzip", "x-gzip":
+		return gzip.NewReader(body)
+	case "deflate":
+		// deflate should be wrapped in zlib, but some servers send the raw stream
+		buffered := bufio.NewReader(body)
+		header, err := buffered.Peek(2)
+		if err == nil && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+			return zlib.NewReader(buffered)
+		}
+		return flate.NewReader(buffered), nil
+	case "br":
+		return ioutil.NopCloser(brotli.NewReader(body)), nil
+	default:
+		decoder, err := zstd.NewReader(body)
+		if err != nil {
+			return nil, err
+		}
+		return decoder.IOReadCloser(), nil
+	}
+}
+
+// reads the body of the response to the request of the url, undoing its content codings and enforcing MaxBodySize.
+// Bodies with unknown content codings are returned as they were received.
+func (s *Session) readBody(resp *http.Response, url string) ([]byte, error) {
+	codings, ok := contentCodings(resp.Header)
+	if !ok {
+		codings = nil
+	}
+	declared := len(codings) > 0
+	// custom transports may not set the request of the response
+	if resp.Request != nil {
+		url = resp.Request.URL.String()
+	}
+	if s.MaxBodySize > 0 && len(codings) == 0 && resp.ContentLength > s.MaxBodySize {
+		return nil, &BodyTooLargeError{url, s.MaxBodySize}
+	}
+	var body io.Reader = resp.Body
+	// there is nothing to decode in responses without body
+	if (resp.Request != nil && resp.Request.Method == http.MethodHead) ||
+		resp.StatusCode == http.StatusNoContent || resp.StatusCode == http.StatusNotModified {
+		codings = nil
+	}
+	if len(codings) > 0 {
+		buffered := bufio.NewReader(resp.Body)
+		if _, err := buffered.Peek(1); err == io.EOF {
+			codings = nil
+		}
+		body = buffered
+	}
+	for position := len(codings) - 1; position >= 0; position-- {
+		decoded, err := decoder(codings[position], body)
+		if err != nil {
+			return nil, err
+		}
+		defer decoded.Close()
+		body = decoded
+	}
+	if s.MaxBodySize > 0 {
+		body = io.LimitReader(body, s.MaxBodySize+1)
+	}
+	content, err := ioutil.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	if s.MaxBodySize > 0 && int64(len(content)) > s.MaxBodySize {
+		return nil, &BodyTooLargeError{url, s.MaxBodySize}
+	}
+	if declared {
+		resp.Header.Del("Content-Encoding")
+		resp.Header.Del("Content-Length")
+	}
+	return content, nil
+}
@@ -0,0 +1,133 @@
+package soup
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+func compress(t *testing.T, coding string, content string) []byte {
+	var buffer bytes.Buffer
+	var writer io.WriteCloser
+	switch coding {
+	case "gzip":
+		writer = gzip.NewWriter(&buffer)
+	case "deflate":
+		writer = zlib.NewWriter(&buffer)
+	case "raw-deflate":
+		writer, _ = flate.NewWriter(&buffer, flate.DefaultCompression)
+	case "br":
+		writer = brotli.NewWriter(&buffer)
+	case "zstd":
+		encoder, err := zstd.NewWriter(&buffer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		writer = encoder
+	}
+	writer.Write([]byte(content))
+	writer.Close()
+	return buffer.Bytes()
+}
+
+func TestDecompression(t *testing.T) {
+	const page = "<html><body><p>compressed</p></body></html>"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		coding := strings.TrimPrefix(r.URL.Path, "/")
+		if r.Header.Get("Accept-Encoding") != acceptEncoding {
+			t.Errorf("Unexpected Accept-Encoding %q", r.Header.Get("Accept-Encoding"))
+		}
+		if coding == "raw-deflate" {
+			w.Header().Set("Content-Encoding", "deflate")
+		} else {
+			w.Header().Set("Content-Encoding", coding)
+		}
+		w.Write(compress(t, coding, page))
+	}))
+	defer ts.Close()
+
+	session := NewSession()
+	for _, coding := range []string{"gzip", "deflate", "raw-deflate", "br", "zstd"} {
+		resp, err := session.Do("GET", ts.URL+"/"+coding, nil)
+		if err != nil {
+			t.Errorf("%s: %v", coding, err)
+			continue
+		}
+		if resp.Body != page {
+			t.Errorf("%s: expected %q, got %q", coding, page, resp.Body)
+		}
+		if resp.Header.Get("Content-Encoding") != "" {
+			t.Errorf("%s: Content-Encoding should be removed after decoding", coding)
+		}
+	}
+}
+
+func TestMaxBodySize(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content := strings.Repeat("a", 1000)
+		if r.URL.Path == "/bomb" {
+			w.Header().Set("Content-Encoding", "gzip")
+			w.Write(compress(t, "gzip", content))
+			return
+		}
+		w.Write([]byte(content))
+	}))
+	defer ts.Close()
+
+	session := NewSession()
+	session.MaxBodySize = 100
+	for _, path := range []string{"/plain", "/bomb"} {
+		_, err := session.Get(ts.URL + path)
+		var tooLarge *BodyTooLargeError
+		if !errors.As(err, &tooLarge) || tooLarge.Limit != 100 {
+			t.Errorf("%s: expected a BodyTooLargeError, got %v", path, err)
+		}
+	}
+	session.MaxBodySize = 1000
+	if body, err := session.Get(ts.URL + "/bomb"); err != nil || len(body) != 1000 {
+		t.Errorf("Body within the limit should be read, got %d bytes (%v)", len(body), err)
+	}
+	session.Client = &http.Client{Transport: bareTransport{}}
+	session.MaxBodySize = 5
+	_, err := session.Get("http://example.com/page")
+	var tooLarge *BodyTooLargeError
+	if !errors.As(err, &tooLarge) || tooLarge.URL != "http://example.com/page" {
+		t.Errorf("Expected a BodyTooLargeError for the requested URL, got %v", err)
+	}
+}
+
+func TestDecompressionWithoutBody(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		switch r.URL.Path {
+		case "/no-content":
+			w.WriteHeader(http.StatusNoContent)
+		case "/not-modified":
+			w.WriteHeader(http.StatusNotModified)
+		case "/empty":
+		default:
+			w.Write(compress(t, "gzip", "page"))
+		}
+	}))
+	defer ts.Close()
+
+	session := NewSession()
+	for _, check := range []struct{ method, path string }{
+		{"HEAD", "/page"}, {"GET", "/no-content"}, {"GET", "/not-modified"}, {"GET", "/empty"},
+	} {
+		resp, err := session.Do(check.method, ts.URL+check.path, nil)
+		if err != nil || resp.Body != "" || resp.Header.Get("Content-Encoding") != "" {
+			t.Errorf("%s %s: expected an empty decoded response, got %+v (%v)", check.method, check.path, resp, err)
+		}
+	}
+}
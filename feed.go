@@ -0,0 +1,204 @@
+package soup
+
+import (
+	"encoding/xml"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Feed is a RSS or Atom feed
+type Feed struct {
+	Title       string
+	Link        string
+	Description string
+	Items       []FeedItem
+}
+
+// FeedItem is an item of a RSS feed or an entry of an Atom feed
+type FeedItem struct {
+	Title string
+	Link  string
+	ID    string
+	// Published falls back to the time of the last update if the feed doesn't state it
+	Published time.Time
+	Updated   time.Time
+	// Summary is the description of a RSS item or the summary of an Atom entry, Content its full content if present.
+	// HTML is returned unescaped, while XHTML content of Atom is returned as the markup inside of its wrapping div.
+	Summary string
+	Content string
+}
+
+// feedXML is the XML structure of RSS 2.0, RSS 1.0 and Atom feeds
+type feedXML struct {
+	XMLName xml.Name
+	// RSS
+	Channel struct {
+		Title       string     `xml:"title"`
+		Links       []feedLink `xml:"link"`
+		Description string     `xml:"description"`
+		Items       []rssItem  `xml:"item"`
+	} `xml:"channel"`
+	// RSS 1.0 places the items next to the channel
+	Items []rssItem `xml:"item"`
+	// Atom
+	Title    string      `xml:"title"`
+	Links    []feedLink  `xml:"link"`
+	Subtitle string      `xml:"subtitle"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+// feedLink is a RSS link with the URL as text or an Atom link with the URL as attribute
+type feedLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Text string `xml:",chardata"`
+}
+
+// rssItem is the XML structure of a RSS item
+type rssItem struct {
+	Title       string     `xml:"title"`
+	Links       []feedLink `xml:"link"`
+	GUID        string     `xml:"guid"`
+	PubDate     string     `xml:"pubDate"`
+	Date        string     `xml:"http://purl.org/dc/elements/1.1/ date"`
+	Description string     `xml:"description"`
+	Content     string     `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+}
+
+// atomEntry is the XML structure of an Atom entry
+type atomEntry struct {
+	Title     string     `xml:"title"`
+	Links     []feedLink `xml:"link"`
+	ID        string     `xml:"id"`
+	Published string     `xml:"published"`
+	Updated   string     `xml:"updated"`
+	Summary   atomText   `xml:"summary"`
+	Content   atomText   `xml:"content"`
+}
+
+// atomText is an Atom text construct, holding text, escaped HTML or XHTML markup depending on its type
+type atomText struct {
+	Type  string `xml:"type,attr"`
+	Text  string `xml:",chardata"`
+	XHTML string `xml:",innerxml"`
+}
+
+// returns the text, the unescaped HTML or the markup inside of the wrapping div of XHTML
+func (t atomText) String() string {
+	if t.Type != "xhtml" {
+		return t.Text
+	}
+	content := strings.TrimSpace(t.XHTML)
+	start := strings.Index(content, ">")
+	if !strings.HasPrefix(content, "<") || start < 0 {
+		return content
+	}
+	if strings.HasSuffix(content[:start], "/") {
+		// an empty div
+		return ""
+	}
+	if end := strings.LastIndex(content, "</"); end > start {
+		return content[start+1 : end]
+	}
+	return content
+}
+
+// the date formats found in RSS feeds, which often deviate from RFC 822
+var rssTimeLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	"2 Jan 2006 15:04:05 MST",
+	"Mon, 02 Jan 2006 15:04 -0700",
+	"Mon, 2 Jan 2006 15:04 MST",
+}
+
+// parses the date of a feed in RFC 822 or W3C format, returning the zero time if it can't be parsed
+func parseFeedTime(value string) time.Time {
+	value = strings.TrimSpace(value)
+	for _, layout := range rssTimeLayouts {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return parsed
+		}
+	}
+	return parseW3CTime(value)
+}
+
+// returns the URL of the alternate link, the link without relation or the first link
+func alternateLink(links []feedLink) string {
+	for _, link := range links {
+		if link.Rel == "" || link.Rel == "alternate" {
+			if href := strings.TrimSpace(link.Href); href != "" {
+				return href
+			}
+			if text := strings.TrimSpace(link.Text); text != "" {
+				return text
+			}
+		}
+	}
+	for _, link := range links {
+		if href := strings.TrimSpace(link.Href + link.Text); href != "" {
+			return href
+		}
+	}
+	return ""
+}
+
+// ParseFeed parses a RSS 2.0, RSS 1.0 or Atom feed
+func ParseFeed(content string) (*Feed, error) {
+	var document feedXML
+	err := decodeXML([]byte(content), &document)
+	switch {
+	case err != nil:
+	case document.XMLName.Local == "rss" || document.XMLName.Local == "RDF":
+		feed := &Feed{
+			Title:       strings.TrimSpace(document.Channel.Title),
+			Link:        alternateLink(document.Channel.Links),
+			Description: strings.TrimSpace(document.Channel.Description),
+		}
+		for _, item := range append(document.Channel.Items, document.Items...) {
+			published := parseFeedTime(item.PubDate)
+			if published.IsZero() {
+				published = parseFeedTime(item.Date)
+			}
+			feed.Items = append(feed.Items, FeedItem{
+				Title:     strings.TrimSpace(item.Title),
+				Link:      alternateLink(item.Links),
+				ID:        strings.TrimSpace(item.GUID),
+				Published: published,
+				Summary:   strings.TrimSpace(item.Description),
+				Content:   strings.TrimSpace(item.Content),
+			})
+		}
+		return feed, nil
+	case document.XMLName.Local == "feed":
+		feed := &Feed{
+			Title:       strings.TrimSpace(document.Title),
+			Link:        alternateLink(document.Links),
+			Description: strings.TrimSpace(document.Subtitle),
+		}
+		for _, entry := range document.Entries {
+			item := FeedItem{
+				Title:     strings.TrimSpace(entry.Title),
+				Link:      alternateLink(entry.Links),
+				ID:        strings.TrimSpace(entry.ID),
+				Published: parseW3CTime(entry.Published),
+				Updated:   parseW3CTime(entry.Updated),
+				Summary:   strings.TrimSpace(entry.Summary.String()),
+				Content:   strings.TrimSpace(entry.Content.String()),
+			}
+			if item.Published.IsZero() {
+				item.Published = item.Updated
+			}
+			feed.Items = append(feed.Items, item)
+		}
+		return feed, nil
+	}
+	if debug {
+		panic("Unable to parse the feed")
+	}
+	return nil, errors.New("unable to parse the feed")
+}
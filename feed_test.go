@@ -0,0 +1,109 @@
+package soup
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseFeedRSS(t *testing.T) {
+	feed, err := ParseFeed(`<?xml version="1.0" encoding="ISO-8859-1"?>
+<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom" xmlns:content="http://purl.org/rss/1.0/modules/content/">
+<channel>
+	<title>News</title>
+	<atom:link href="https://example.com/feed.xml" rel="self" type="application/rss+xml"/>
+	<link>https://example.com/</link>
+	<description>Latest news</description>
+	<item>
+		<title>Caf` + "\xe9" + ` opens</title>
+		<link>https://example.com/cafe</link>
+		<guid>cafe-1</guid>
+		<pubDate>Tue, 5 Mar 2024 09:00:00 GMT</pubDate>
+		<description>&lt;p&gt;Short&lt;/p&gt;</description>
+		<content:encoded><![CDATA[<p>Full story</p>]]></content:encoded>
+	</item>
+</channel>
+</rss>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if feed.Title != "News" || feed.Link != "https://example.com/" || feed.Description != "Latest news" || len(feed.Items) != 1 {
+		t.Fatalf("Unexpected feed %+v", feed)
+	}
+	item := feed.Items[0]
+	if item.Title != "Café opens" || item.Link != "https://example.com/cafe" || item.ID != "cafe-1" ||
+		item.Summary != "<p>Short</p>" || item.Content != "<p>Full story</p>" ||
+		!item.Published.Equal(time.Date(2024, 3, 5, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected item %+v", item)
+	}
+}
+
+func TestParseFeedRDF(t *testing.T) {
+	feed, err := ParseFeed(`<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns="http://purl.org/rss/1.0/" xmlns:dc="http://purl.org/dc/elements/1.1/">
+	<channel><title>Old school</title><link>https://example.org/</link></channel>
+	<item><title>First</title><link>https://example.org/1</link><dc:date>2003-12-13T18:30:02Z</dc:date></item>
+</rdf:RDF>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if feed.Title != "Old school" || len(feed.Items) != 1 || feed.Items[0].Link != "https://example.org/1" ||
+		!feed.Items[0].Published.Equal(time.Date(2003, 12, 13, 18, 30, 2, 0, time.UTC)) {
+		t.Errorf("Unexpected feed %+v", feed)
+	}
+}
+
+func TestParseFeedAtom(t *testing.T) {
+	feed, err := ParseFeed(`<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+	<title>Blog</title>
+	<subtitle>Thoughts</subtitle>
+	<link href="https://blog.example.com/atom.xml" rel="self"/>
+	<link href="https://blog.example.com/"/>
+	<entry>
+		<title>Hello</title>
+		<link rel="alternate" href="https://blog.example.com/hello"/>
+		<id>urn:uuid:1</id>
+		<updated>2024-02-01T12:00:00Z</updated>
+		<summary>Greeting</summary>
+	</entry>
+</feed>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if feed.Title != "Blog" || feed.Link != "https://blog.example.com/" || feed.Description != "Thoughts" || len(feed.Items) != 1 {
+		t.Fatalf("Unexpected feed %+v", feed)
+	}
+	item := feed.Items[0]
+	updated := time.Date(2024, 2, 1, 12, 0, 0, 0, time.UTC)
+	if item.Title != "Hello" || item.Link != "https://blog.example.com/hello" || item.ID != "urn:uuid:1" ||
+		item.Summary != "Greeting" || !item.Updated.Equal(updated) || !item.Published.Equal(updated) {
+		t.Errorf("Unexpected entry %+v", item)
+	}
+	if _, err := ParseFeed("not xml"); err == nil {
+		t.Errorf("Expected an error for invalid content")
+	}
+}
+
+func TestParseFeedAtomContent(t *testing.T) {
+	feed, err := ParseFeed(`<feed xmlns="http://www.w3.org/2005/Atom">
+	<entry>
+		<title>XHTML</title>
+		<summary type="html">&lt;p&gt;Escaped &amp;amp; unescaped&lt;/p&gt;</summary>
+		<content type="xhtml">
+			<div xmlns="http://www.w3.org/1999/xhtml"><p>Hello <b>world</b></p></div>
+		</content>
+	</entry>
+	<entry>
+		<title>Empty</title>
+		<content type="xhtml"><div xmlns="http://www.w3.org/1999/xhtml"/></content>
+	</entry>
+</feed>`)
+	if err != nil || len(feed.Items) != 2 {
+		t.Fatalf("Unexpected feed %+v (%v)", feed, err)
+	}
+	if item := feed.Items[0]; item.Summary != "<p>Escaped &amp; unescaped</p>" || item.Content != "<p>Hello <b>world</b></p>" {
+		t.Errorf("Unexpected content %q %q", item.Summary, item.Content)
+	}
+	if item := feed.Items[1]; item.Content != "" {
+		t.Errorf("Expected no content for an empty div, got %q", item.Content)
+	}
+}
@@ -0,0 +1,264 @@
+package soup
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// File is a file sent as part of a multipart request
+type File struct {
+	Field       string
+	Name        string
+	ContentType string
+	Content     io.Reader
+}
+
+// Form is a HTML form together with the values it submits
+type Form struct {
+	Element Root
+	Action  string
+	Method  string
+	Enctype string
+	Values  url.Values
+	Files   []File
+}
+
+// Forms returns all forms beneath the element with the values they would submit by default,
+// including hidden fields like CSRF tokens.
+// The actions of the forms are resolved against the passed page URL, or the base URL of the document.
+func (r Root) Forms(pageURL ...string) []Form {
+	base := r.BaseURL
+	if len(pageURL) == 1 && pageURL[0] != "" {
+		parsed, err := url.Parse(pageURL[0])
+		if err != nil {
+			if debug {
+				panic("Unable to parse the page URL " + pageURL[0])
+			}
+		} else {
+			base = parsed
+		}
+	}
+	var forms []Form
+	elements := r.findAll([]string{"form"}, true, false)
+	for _, element := range elements {
+		forms = append(forms, newForm(element, base))
+	}
+	return forms
+}
+
+// creates the form with its default values from the form element
+func newForm(element Root, base *url.URL) Form {
+	form := Form{
+		Element: element,
+		Action:  strings.TrimSpace(attributeOrEmpty(element.Pointer, "action")),
+		Method:  strings.ToUpper(strings.TrimSpace(attributeOrEmpty(element.Pointer, "method"))),
+		Enctype: strings.ToLower(strings.TrimSpace(attributeOrEmpty(element.Pointer, "enctype"))),
+		Values:  make(url.Values),
+	}
+	if form.Method != "POST" {
+		form.Method = "GET"
+	}
+	if form.Enctype != "multipart/form-data" {
+		form.Enctype = "application/x-www-form-urlencoded"
+	}
+	if base != nil {
+		if action, err := url.Parse(form.Action); err == nil {
+			form.Action = base.ResolveReference(action).String()
+		}
+	}
+
+	var collect func(*html.Node)
+	collect = func(n *html.Node) {
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type != html.ElementNode {
+				continue
+			}
+			name := attributeOrEmpty(child, "name")
+			_, disabled := attributeValue(child, "disabled")
+			if name == "" || disabled {
+				collect(child)
+				continue
+			}
+			switch child.Data {
+			case "input":
+				form.addInput(child, name)
+			case "select":
+				form.addSelect(child, name)
+			case "textarea":
+				form.Values.Add(name, strings.TrimPrefix(rawText(child), "\n"))
+			default:
+				collect(child)
+			}
+		}
+	}
+	collect(element.Pointer)
+	return form
+}
+
+// adds the value of an input element
+func (f *Form) addInput(n *html.Node, name string) {
+	value, hasValue := attributeValue(n, "value")
+	switch strings.ToLower(attributeOrEmpty(n, "type")) {
+	case "submit", "button", "reset", "image":
+		// only the button used to submit a form is sent, which is up to the caller
+	case "checkbox", "radio":
+		if _, checked := attributeValue(n, "checked"); checked {
+			if !hasValue {
+				value = "on"
+			}
+			f.Values.Add(name, value)
+		}
+	case "file":
+		f.Files = append(f.Files, File{Field: name, ContentType: "application/octet-stream"})
+	default:
+		f.Values.Add(name, value)
+	}
+}
+
+// adds the selected options of a select element, the first option is selected by default
+func (f *Form) addSelect(n *html.Node, name string) {
+	_, multiple := attributeValue(n, "multiple")
+	var options []*html.Node
+	var collect func(*html.Node)
+	collect = func(n *html.Node) {
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type == html.ElementNode && child.Data == "option" {
+				options = append(options, child)
+			} else if child.Type == html.ElementNode && child.Data == "optgroup" {
+				collect(child)
+			}
+		}
+	}
+	collect(n)
+	optionValue := func(option *html.Node) string {
+		if value, ok := attributeValue(option, "value"); ok {
+			return value
+		}
+		return strings.TrimSpace(collapseWhitespace(rawText(option)))
+	}
+	selected := false
+	for _, option := range options {
+		if _, ok := attributeValue(option, "selected"); ok {
+			f.Values.Add(name, optionValue(option))
+			selected = true
+			if !multiple {
+				break
+			}
+		}
+	}
+	if !selected && !multiple && len(options) > 0 {
+		f.Values.Add(name, optionValue(options[0]))
+	}
+}
+
+// Set sets the value of the field, replacing all existing values
+func (f *Form) Set(name string, value string) {
+	f.Values.Set(name, value)
+}
+
+// SetFile sets the file sent for the field, the form is submitted as multipart/form-data
+func (f *Form) SetFile(field string, name string, content io.Reader) {
+	file := File{field, name, "application/octet-stream", content}
+	for position := range f.Files {
+		if f.Files[position].Field == field {
+			f.Files[position] = file
+			return
+		}
+	}
+	f.Files = append(f.Files, file)
+}
+
+// Submit submits the form using the default HTTP client and returns the HTML of the response
+func (f Form) Submit() (string, error) {
+	return f.SubmitWithClient(&http.Client{})
+}
+
+// SubmitWithClient submits the form using a provided HTTP client and returns the HTML of the response.
+// GET forms send their values in the query string, POST forms either urlencoded or as multipart/form-data.
+func (f Form) SubmitWithClient(client *http.Client) (string, error) {
+	resp, err := globalSession(client).Submit(f)
+	if err != nil {
+		return "", err
+	}
+	return resp.Body, nil
+}
+
+// returns the method, URL, body and content type used to submit the form
+func (f Form) request() (string, string, io.Reader, string, error) {
+	action, err := url.Parse(f.Action)
+	if err != nil {
+		return "", "", nil, "", errors.New("unable to parse the form action " + f.Action)
+	}
+	if f.Method != "POST" {
+		action.RawQuery = f.Values.Encode()
+		return "GET", action.String(), nil, "", nil
+	}
+	files := false
+	for _, file := range f.Files {
+		files = files || file.Content != nil
+	}
+	if f.Enctype != "multipart/form-data" && !files {
+		return "POST", action.String(), strings.NewReader(f.Values.Encode()), "application/x-www-form-urlencoded", nil
+	}
+	body, contentType, err := encodeMultipart(f.Values, f.Files)
+	if err != nil {
+		return "", "", nil, "", err
+	}
+	return "POST", action.String(), body, contentType, nil
+}
+
+// encodes the values and files as multipart/form-data, returning the body and its content type
+func encodeMultipart(values url.Values, files []File) (io.Reader, string, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range values[name] {
+			if err := writer.WriteField(name, value); err != nil {
+				return nil, "", err
+			}
+		}
+	}
+	for _, file := range files {
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", `form-data; name="`+escapeQuotes(file.Field)+`"; filename="`+escapeQuotes(file.Name)+`"`)
+		contentType := file.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		header.Set("Content-Type", contentType)
+		part, err := writer.CreatePart(header)
+		if err != nil {
+			return nil, "", err
+		}
+		if file.Content != nil {
+			if _, err := io.Copy(part, file.Content); err != nil {
+				return nil, "", errors.New("unable to read the file " + file.Name)
+			}
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, "", err
+	}
+	return &body, writer.FormDataContentType(), nil
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// escapes quotes and backslashes for a header parameter
+func escapeQuotes(s string) string {
+	return quoteEscaper.Replace(s)
+}
@@ -0,0 +1,114 @@
+package soup
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const formHTML = `
+<html>
+  <body>
+    <form action="/search" id="search">
+      <input type="text" name="q" value="soup">
+      <input type="submit" name="go" value="Search">
+    </form>
+    <form action="login" method="post" id="login">
+      <input type="hidden" name="csrf" value="token123">
+      <input type="text" name="user">
+      <input type="password" name="password">
+      <input type="checkbox" name="remember" checked>
+      <input type="checkbox" name="newsletter" value="yes">
+      <input type="radio" name="plan" value="free">
+      <input type="radio" name="plan" value="pro" checked>
+      <input type="text" name="disabled" value="x" disabled>
+      <select name="lang"><option value="en">English</option><option selected>Deutsch</option></select>
+      <select name="size"><optgroup label="sizes"><option>S</option><option>M</option></optgroup></select>
+      <textarea name="note">
+Hello</textarea>
+    </form>
+    <form action="/upload" method="POST" enctype="multipart/form-data" id="upload">
+      <input type="text" name="title" value="report">
+      <input type="file" name="document">
+    </form>
+  </body>
+</html>
+`
+
+func TestForms(t *testing.T) {
+	forms := HTMLParse(formHTML).Forms("https://example.com/account/")
+	if len(forms) != 3 {
+		t.Fatalf("Expected 3 forms, got %d", len(forms))
+	}
+	if forms[0].Action != "https://example.com/search" || forms[0].Method != "GET" {
+		t.Errorf("Wrong form: %+v", forms[0])
+	}
+	if !reflect.DeepEqual(map[string][]string(forms[0].Values), map[string][]string{"q": {"soup"}}) {
+		t.Errorf("Wrong values: %v", forms[0].Values)
+	}
+	login := forms[1]
+	if login.Action != "https://example.com/account/login" || login.Method != "POST" || login.Enctype != "application/x-www-form-urlencoded" {
+		t.Errorf("Wrong form: %+v", login)
+	}
+	expected := map[string][]string{
+		"csrf":     {"token123"},
+		"user":     {""},
+		"password": {""},
+		"remember": {"on"},
+		"plan":     {"pro"},
+		"lang":     {"Deutsch"},
+		"size":     {"S"},
+		"note":     {"Hello"},
+	}
+	if !reflect.DeepEqual(map[string][]string(login.Values), expected) {
+		t.Errorf("Wrong values: %v", login.Values)
+	}
+	if len(forms[2].Files) != 1 || forms[2].Files[0].Field != "document" {
+		t.Errorf("Wrong files: %+v", forms[2].Files)
+	}
+}
+
+func TestFormSubmit(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+			file, header, err := r.FormFile("document")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			content, _ := ioutil.ReadAll(file)
+			w.Write([]byte(r.Method + " " + r.URL.Path + " " + r.FormValue("title") + " " + header.Filename + " " + string(content)))
+			return
+		}
+		r.ParseForm()
+		w.Write([]byte(r.Method + " " + r.URL.Path + " " + r.Form.Encode() + " " + r.Header.Get("X-Test")))
+	}))
+	defer ts.Close()
+	Header("X-Test", "header")
+	defer delete(Headers, "X-Test")
+
+	forms := HTMLParse(formHTML).Forms(ts.URL + "/account/")
+
+	forms[0].Set("q", "go soup")
+	actual, err := forms[0].Submit()
+	if err != nil || actual != "GET /search q=go+soup header" {
+		t.Errorf("Wrong response: %s (%v)", actual, err)
+	}
+
+	forms[1].Set("user", "alice")
+	forms[1].Set("password", "secret")
+	actual, err = forms[1].Submit()
+	expected := "POST /account/login csrf=token123&lang=Deutsch&note=Hello&password=secret&plan=pro&remember=on&size=S&user=alice header"
+	if err != nil || actual != expected {
+		t.Errorf("Wrong response: %s (%v)", actual, err)
+	}
+
+	forms[2].SetFile("document", "report.txt", strings.NewReader("content"))
+	actual, err = forms[2].Submit()
+	if err != nil || actual != "POST /upload report report.txt content" {
+		t.Errorf("Wrong response: %s (%v)", actual, err)
+	}
+}
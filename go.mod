@@ -0,0 +1,11 @@
+module github.com/anaskhan96/soup
+
+go 1.23
+
+require (
+	github.com/andybalholm/brotli v1.2.0
+	github.com/klauspost/compress v1.18.0
+	golang.org/x/net v0.35.0
+)
+
+require golang.org/x/text v0.22.0 // indirect
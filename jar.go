@@ -0,0 +1,295 @@
+package soup
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// Jar is a cookie jar which scopes cookies by domain and path, respecting the public suffix list.
+// In contrast to net/http/cookiejar the cookies can be saved to and loaded from disk,
+// either as JSON or in the Netscape cookies.txt format.
+type Jar struct {
+	mu      sync.Mutex
+	jar     *cookiejar.Jar
+	entries map[string]JarCookie
+}
+
+// JarCookie is a cookie stored in the jar together with its scope
+type JarCookie struct {
+	Name     string    `json:"name"`
+	Value    string    `json:"value"`
+	Domain   string    `json:"domain"`
+	HostOnly bool      `json:"hostOnly"`
+	Path     string    `json:"path"`
+	Secure   bool      `json:"secure"`
+	HttpOnly bool      `json:"httpOnly"`
+	Expires  time.Time `json:"expires,omitempty"`
+}
+
+// NewJar creates an empty cookie jar
+func NewJar() *Jar {
+	jar, _ := cookiejar.New(&cookiejar.Options{PublicSuffixList: publicsuffix.List})
+	return &Jar{jar: jar, entries: make(map[string]JarCookie)}
+}
+
+// SetCookies stores the cookies received from the url, implementing http.CookieJar
+func (j *Jar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.jar.SetCookies(u, cookies)
+	host := canonicalHost(u.Host)
+	now := time.Now()
+	for _, cookie := range cookies {
+		entry := JarCookie{
+			Name:     cookie.Name,
+			Value:    cookie.Value,
+			Domain:   host,
+			HostOnly: true,
+			Path:     cookie.Path,
+			Secure:   cookie.Secure,
+			HttpOnly: cookie.HttpOnly,
+		}
+		if cookie.Domain != "" {
+			domain := strings.TrimPrefix(strings.ToLower(cookie.Domain), ".")
+			if !domainMatches(host, domain) {
+				continue
+			}
+			// a domain attribute naming an IP address leaves the cookie host-only
+			entry.HostOnly = net.ParseIP(host) != nil
+			entry.Domain = domain
+		}
+		if entry.Path == "" || entry.Path[0] != '/' {
+			entry.Path = defaultCookiePath(u.Path)
+		}
+		key := entry.Domain + ";" + entry.Path + ";" + entry.Name
+		switch {
+		case cookie.MaxAge < 0:
+			delete(j.entries, key)
+			continue
+		case cookie.MaxAge > 0:
+			entry.Expires = now.Add(time.Duration(cookie.MaxAge) * time.Second)
+		case !cookie.Expires.IsZero():
+			entry.Expires = cookie.Expires
+		}
+		if !entry.Expires.IsZero() && !entry.Expires.After(now) {
+			delete(j.entries, key)
+			continue
+		}
+		j.entries[key] = entry
+	}
+}
+
+// Cookies returns the cookies to send to the url, implementing http.CookieJar
+func (j *Jar) Cookies(u *url.URL) []*http.Cookie {
+	return j.jar.Cookies(u)
+}
+
+// All returns all cookies which are not expired yet, ordered by domain, path and name
+func (j *Jar) All() []JarCookie {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	now := time.Now()
+	var cookies []JarCookie
+	for _, entry := range j.entries {
+		if entry.Expires.IsZero() || entry.Expires.After(now) {
+			cookies = append(cookies, entry)
+		}
+	}
+	sort.Slice(cookies, func(a, b int) bool {
+		if cookies[a].Domain != cookies[b].Domain {
+			return cookies[a].Domain < cookies[b].Domain
+		}
+		if cookies[a].Path != cookies[b].Path {
+			return cookies[a].Path < cookies[b].Path
+		}
+		return cookies[a].Name < cookies[b].Name
+	})
+	return cookies
+}
+
+// Add stores the cookie with its scope, as if it was received from its domain
+func (j *Jar) Add(cookie JarCookie) {
+	scheme := "http"
+	if cookie.Secure {
+		scheme = "https"
+	}
+	u := &url.URL{Scheme: scheme, Host: cookie.Domain, Path: cookie.Path}
+	httpCookie := &http.Cookie{
+		Name:     cookie.Name,
+		Value:    cookie.Value,
+		Path:     cookie.Path,
+		Secure:   cookie.Secure,
+		HttpOnly: cookie.HttpOnly,
+		Expires:  cookie.Expires,
+	}
+	if !cookie.HostOnly {
+		httpCookie.Domain = cookie.Domain
+	}
+	j.SetCookies(u, []*http.Cookie{httpCookie})
+}
+
+// WriteJSON writes all cookies as JSON array
+func (j *Jar) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(j.All())
+}
+
+// ReadJSON adds the cookies of a JSON array written by WriteJSON
+func (j *Jar) ReadJSON(r io.Reader) error {
+	var cookies []JarCookie
+	if err := json.NewDecoder(r).Decode(&cookies); err != nil {
+		return errors.New("unable to decode the cookies: " + err.Error())
+	}
+	for _, cookie := range cookies {
+		j.Add(cookie)
+	}
+	return nil
+}
+
+// WriteNetscape writes all cookies in the Netscape cookies.txt format, session cookies get an expiry of 0
+func (j *Jar) WriteNetscape(w io.Writer) error {
+	writer := bufio.NewWriter(w)
+	writer.WriteString("# Netscape HTTP Cookie File\n")
+	for _, cookie := range j.All() {
+		domain := cookie.Domain
+		if !cookie.HostOnly {
+			domain = "." + domain
+		}
+		if cookie.HttpOnly {
+			domain = "#HttpOnly_" + domain
+		}
+		expires := int64(0)
+		if !cookie.Expires.IsZero() {
+			expires = cookie.Expires.Unix()
+		}
+		fields := []string{
+			domain,
+			netscapeBool(!cookie.HostOnly),
+			cookie.Path,
+			netscapeBool(cookie.Secure),
+			strconv.FormatInt(expires, 10),
+			cookie.Name,
+			cookie.Value,
+		}
+		writer.WriteString(strings.Join(fields, "\t") + "\n")
+	}
+	return writer.Flush()
+}
+
+// ReadNetscape adds the cookies of a file in the Netscape cookies.txt format
+func (j *Jar) ReadNetscape(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimRight(scanner.Text(), "\r")
+		httpOnly := strings.HasPrefix(text, "#HttpOnly_")
+		text = strings.TrimPrefix(text, "#HttpOnly_")
+		if strings.TrimSpace(text) == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Split(text, "\t")
+		if len(fields) != 7 {
+			return errors.New("invalid cookie in line " + strconv.Itoa(line))
+		}
+		expires, err := strconv.ParseInt(fields[4], 10, 64)
+		if err != nil {
+			return errors.New("invalid expiry in line " + strconv.Itoa(line))
+		}
+		cookie := JarCookie{
+			Name:     fields[5],
+			Value:    fields[6],
+			Domain:   strings.TrimPrefix(strings.ToLower(fields[0]), "."),
+			HostOnly: strings.ToUpper(fields[1]) != "TRUE",
+			Path:     fields[2],
+			Secure:   strings.ToUpper(fields[3]) == "TRUE",
+			HttpOnly: httpOnly,
+		}
+		if expires > 0 {
+			cookie.Expires = time.Unix(expires, 0)
+		}
+		j.Add(cookie)
+	}
+	return scanner.Err()
+}
+
+// Save writes all cookies into the file, using the Netscape format for files ending in .txt and JSON otherwise
+func (j *Jar) Save(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if strings.HasSuffix(path, ".txt") {
+		err = j.WriteNetscape(file)
+	} else {
+		err = j.WriteJSON(file)
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Load adds the cookies of a file written by Save
+func (j *Jar) Load(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if strings.HasSuffix(path, ".txt") {
+		return j.ReadNetscape(file)
+	}
+	return j.ReadJSON(file)
+}
+
+// returns the lowercase host without port
+func canonicalHost(host string) string {
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		host = hostname
+	}
+	return strings.ToLower(strings.TrimSuffix(host, "."))
+}
+
+// checks if the cookie domain may be set by the host
+func domainMatches(host string, domain string) bool {
+	if domain != host && (!strings.HasSuffix(host, "."+domain) || net.ParseIP(host) != nil) {
+		return false
+	}
+	suffix, _ := publicsuffix.PublicSuffix(domain)
+	return suffix != domain || domain == host
+}
+
+// returns the default path of a cookie set by the request path
+func defaultCookiePath(path string) string {
+	if path == "" || path[0] != '/' {
+		return "/"
+	}
+	last := strings.LastIndex(path, "/")
+	if last == 0 {
+		return "/"
+	}
+	return path[:last]
+}
+
+func netscapeBool(b bool) string {
+	if b {
+		return "TRUE"
+	}
+	return "FALSE"
+}
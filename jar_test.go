@@ -0,0 +1,105 @@
+package soup
+
+import (
+	"bytes"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func mustParseURL(s string) *url.URL {
+	u, err := url.Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return u
+}
+
+func TestJarScope(t *testing.T) {
+	jar := NewJar()
+	jar.SetCookies(mustParseURL("https://www.example.com/account/login"), []*http.Cookie{
+		{Name: "session", Value: "1"},
+		{Name: "shared", Value: "2", Domain: ".example.com", Path: "/"},
+		{Name: "suffix", Value: "3", Domain: "com"},
+	})
+	cookies := jar.Cookies(mustParseURL("https://www.example.com/account/settings"))
+	if len(cookies) != 2 {
+		t.Errorf("Expected 2 cookies, got %v", cookies)
+	}
+	cookies = jar.Cookies(mustParseURL("https://shop.example.com/"))
+	if len(cookies) != 1 || cookies[0].Name != "shared" {
+		t.Errorf("Expected only the domain cookie, got %v", cookies)
+	}
+	if len(jar.Cookies(mustParseURL("https://www.example.org/"))) != 0 {
+		t.Errorf("Cookies should not be sent to other domains")
+	}
+	expected := []JarCookie{
+		{Name: "shared", Value: "2", Domain: "example.com", Path: "/"},
+		{Name: "session", Value: "1", Domain: "www.example.com", HostOnly: true, Path: "/account"},
+	}
+	if !reflect.DeepEqual(jar.All(), expected) {
+		t.Errorf("Wrong cookies: %+v", jar.All())
+	}
+
+	jar.SetCookies(mustParseURL("https://www.example.com/"), []*http.Cookie{{Name: "shared", Domain: "example.com", Path: "/", MaxAge: -1}})
+	if len(jar.All()) != 1 {
+		t.Errorf("Deleted cookie should be removed: %+v", jar.All())
+	}
+}
+
+func TestJarPersistence(t *testing.T) {
+	expires := time.Now().Add(time.Hour).Truncate(time.Second)
+	jar := NewJar()
+	jar.SetCookies(mustParseURL("https://example.com/"), []*http.Cookie{
+		{Name: "id", Value: "abc", Path: "/", Secure: true, HttpOnly: true, Expires: expires},
+		{Name: "lang", Value: "en", Domain: "example.com", Path: "/"},
+	})
+	dir, err := os.MkdirTemp("", "soup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{"cookies.json", "cookies.txt"} {
+		path := filepath.Join(dir, name)
+		if err := jar.Save(path); err != nil {
+			t.Fatal(err)
+		}
+		loaded := NewJar()
+		if err := loaded.Load(path); err != nil {
+			t.Fatal(err)
+		}
+		if len(loaded.All()) != 2 {
+			t.Fatalf("Expected 2 cookies loaded from %s, got %+v", name, loaded.All())
+		}
+		for position, cookie := range loaded.All() {
+			original := jar.All()[position]
+			if cookie.Name != original.Name || cookie.Domain != original.Domain || cookie.HostOnly != original.HostOnly ||
+				cookie.Secure != original.Secure || cookie.HttpOnly != original.HttpOnly || !cookie.Expires.Equal(original.Expires) {
+				t.Errorf("Cookie loaded from %s differs: %+v, %+v", name, cookie, original)
+			}
+		}
+		if len(loaded.Cookies(mustParseURL("https://example.com/page"))) != 2 {
+			t.Errorf("Loaded cookies should be sent")
+		}
+	}
+}
+
+func TestJarNetscapeFormat(t *testing.T) {
+	jar := NewJar()
+	jar.SetCookies(mustParseURL("http://example.com/"), []*http.Cookie{{Name: "lang", Value: "en", Domain: "example.com", Path: "/", HttpOnly: true}})
+	var buf bytes.Buffer
+	jar.WriteNetscape(&buf)
+	expected := "# Netscape HTTP Cookie File\n#HttpOnly_.example.com\tTRUE\t/\tFALSE\t0\tlang\ten\n"
+	if buf.String() != expected {
+		t.Errorf("Wrong cookies.txt:\n%s", buf.String())
+	}
+	if err := NewJar().ReadNetscape(strings.NewReader("example.com\tFALSE\t/\n")); err == nil {
+		t.Errorf("Invalid line should return an error")
+	}
+}
@@ -0,0 +1,291 @@
+package soup
+
+import (
+	"errors"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// linkAttributes maps the elements referencing other resources to the attribute holding the URL
+var linkAttributes = map[string]string{
+	"a":      "href",
+	"area":   "href",
+	"link":   "href",
+	"img":    "src",
+	"script": "src",
+	"iframe": "src",
+	"form":   "action",
+}
+
+// returns the base URL of the document: its <base href> resolved against the page URL, or the page URL itself
+func documentBase(n *html.Node, pageURL string) *url.URL {
+	var base *url.URL
+	if pageURL != "" {
+		parsed, err := url.Parse(pageURL)
+		if err != nil {
+			if debug {
+				panic("Unable to parse the page URL " + pageURL)
+			}
+		} else {
+			base = parsed
+		}
+	}
+	baseElement, ok := Root{nil, n, n.Data, nil, nil}.findOnce([]string{"base"}, true, false)
+	if !ok {
+		return base
+	}
+	href, ok := attributeValue(baseElement.Pointer, "href")
+	if !ok {
+		return base
+	}
+	reference, err := url.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return base
+	}
+	if base == nil {
+		if !reference.IsAbs() {
+			return nil
+		}
+		return reference
+	}
+	return base.ResolveReference(reference)
+}
+
+// AbsURL returns the URL in the attribute of the element resolved against the base URL of the document
+func (r Root) AbsURL(attribute string) (*url.URL, error) {
+	if r.Pointer == nil {
+		if debug {
+			panic("Element is empty")
+		}
+		return nil, errors.New("element is empty")
+	}
+	value, ok := attributeValue(r.Pointer, attribute)
+	if !ok {
+		if debug {
+			panic("Attribute `" + attribute + "` not found")
+		}
+		return nil, errors.New("attribute `" + attribute + "` not found")
+	}
+	link, err := r.absURL(attribute)
+	if err != nil {
+		if debug {
+			panic("Unable to resolve the url " + value)
+		}
+		return nil, errors.New("unable to resolve the url " + value)
+	}
+	return link, nil
+}
+
+// Links returns the resolved URLs referenced by the a, area, link, img, script, iframe and form elements
+// beneath the element in document order, skipping URLs which can't be resolved
+func (r Root) Links() []*url.URL {
+	var links []*url.URL
+	var collect func(element Root)
+	collect = func(element Root) {
+		if attribute, ok := linkAttributes[element.Pointer.Data]; ok {
+			if value, ok := attributeValue(element.Pointer, attribute); ok && strings.TrimSpace(value) != "" {
+				if link, err := element.absURL(attribute); err == nil {
+					links = append(links, link)
+				}
+			}
+		}
+		for _, child := range element.Children() {
+			collect(child)
+		}
+	}
+	collect(r)
+	return links
+}
+
+// resolves the URL in the attribute against the base URL
+func (r Root) absURL(attribute string) (*url.URL, error) {
+	reference, err := url.Parse(strings.TrimSpace(attributeOrEmpty(r.Pointer, attribute)))
+	if err != nil {
+		return nil, err
+	}
+	if r.BaseURL != nil {
+		return r.BaseURL.ResolveReference(reference), nil
+	}
+	if !reference.IsAbs() {
+		return nil, errors.New("relative url without base")
+	}
+	return reference, nil
+}
+
+// LinkKind classifies the target of a link
+type LinkKind int
+
+const (
+	// PageLink points to a HTTP(S) page
+	PageLink LinkKind = iota
+	// FragmentLink points to a fragment of the same page, like #top
+	FragmentLink
+	// MailLink is a mailto: link
+	MailLink
+	// PhoneLink is a tel: link
+	PhoneLink
+	// ScriptLink is a javascript: link
+	ScriptLink
+	// OtherLink uses any other scheme, like ftp: or data:
+	OtherLink
+)
+
+// Link is a link found by ExtractLinks
+type Link struct {
+	// URL is resolved against the base URL of the document if it has one
+	URL  *url.URL
+	Text string
+	// Rel contains the values of the rel attribute in lowercase, like nofollow
+	Rel  []string
+	Kind LinkKind
+	// Internal is true for links to the host of the document and for relative links of documents without base URL
+	Internal bool
+}
+
+// NoFollow checks if the link is marked with rel="nofollow"
+func (l Link) NoFollow() bool {
+	for _, rel := range l.Rel {
+		if rel == "nofollow" {
+			return true
+		}
+	}
+	return false
+}
+
+// LinkOptions filters the links returned by ExtractLinks, the zero value keeps every link
+type LinkOptions struct {
+	// Kinds keeps the links of the kinds, all kinds if it is empty
+	Kinds []LinkKind
+	// Hosts keeps the links to the hosts and their subdomains
+	Hosts []string
+	// PathPrefixes keeps the links whose path starts with one of the prefixes
+	PathPrefixes []string
+	// Pattern keeps the links whose URL matches the expression
+	Pattern *regexp.Regexp
+	// InternalOnly keeps the internal links, ExternalOnly the external ones
+	InternalOnly bool
+	ExternalOnly bool
+	// SkipNoFollow drops the links marked with rel="nofollow"
+	SkipNoFollow bool
+}
+
+// checks if the link passes the filters
+func (o LinkOptions) keep(link Link) bool {
+	if len(o.Kinds) > 0 {
+		matching := false
+		for _, kind := range o.Kinds {
+			matching = matching || kind == link.Kind
+		}
+		if !matching {
+			return false
+		}
+	}
+	if len(o.Hosts) > 0 {
+		matching := false
+		host := canonicalHost(link.URL.Host)
+		for _, allowed := range o.Hosts {
+			allowed = canonicalHost(allowed)
+			matching = matching || host == allowed || strings.HasSuffix(host, "."+allowed)
+		}
+		if !matching {
+			return false
+		}
+	}
+	if len(o.PathPrefixes) > 0 {
+		matching := false
+		for _, prefix := range o.PathPrefixes {
+			matching = matching || strings.HasPrefix(link.URL.Path, prefix)
+		}
+		if !matching {
+			return false
+		}
+	}
+	if o.Pattern != nil && !o.Pattern.MatchString(link.URL.String()) {
+		return false
+	}
+	if (o.InternalOnly && !link.Internal) || (o.ExternalOnly && link.Internal) {
+		return false
+	}
+	return !o.SkipNoFollow || !link.NoFollow()
+}
+
+// returns the kind of the link target
+func linkKind(href string, target *url.URL) LinkKind {
+	if strings.HasPrefix(href, "#") {
+		return FragmentLink
+	}
+	switch strings.ToLower(target.Scheme) {
+	case "", "http", "https":
+		return PageLink
+	case "mailto":
+		return MailLink
+	case "tel":
+		return PhoneLink
+	case "javascript":
+		return ScriptLink
+	}
+	return OtherLink
+}
+
+// ExtractLinks returns the links of the a and area elements beneath the element in document order,
+// classified by their target and filtered by the options. Links to the same URL are returned once.
+func (r Root) ExtractLinks(options ...LinkOptions) []Link {
+	var filter LinkOptions
+	if len(options) == 1 {
+		filter = options[0]
+	}
+	var links []Link
+	seen := make(map[string]bool)
+	var collect func(n *html.Node)
+	collect = func(n *html.Node) {
+		if n.Type == html.ElementNode && (n.Data == "a" || n.Data == "area") {
+			if href, ok := attributeValue(n, "href"); ok {
+				if link, ok := r.newLink(n, strings.TrimSpace(href)); ok && !seen[link.URL.String()] && filter.keep(link) {
+					seen[link.URL.String()] = true
+					links = append(links, link)
+				}
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			collect(child)
+		}
+	}
+	collect(r.Pointer)
+	return links
+}
+
+// creates the link of the element, false if its URL can't be parsed
+func (r Root) newLink(n *html.Node, href string) (Link, bool) {
+	reference, err := url.Parse(href)
+	if err != nil {
+		return Link{}, false
+	}
+	link := Link{
+		URL:  reference,
+		Text: strings.TrimSpace(collapseWhitespace(rawText(n))),
+		Rel:  strings.Fields(strings.ToLower(attributeOrEmpty(n, "rel"))),
+		Kind: linkKind(href, reference),
+	}
+	if link.Text == "" {
+		// image links are described by the alternative text of the image, areas by their own
+		if image, ok := (Root{nil, n, n.Data, nil, nil}).findOnce([]string{"img"}, false, false); ok {
+			link.Text = strings.TrimSpace(attributeOrEmpty(image.Pointer, "alt"))
+		} else {
+			link.Text = strings.TrimSpace(attributeOrEmpty(n, "alt"))
+		}
+	}
+	if r.BaseURL != nil {
+		link.URL = r.BaseURL.ResolveReference(reference)
+	}
+	// host names are case insensitive, lowercasing them lets duplicates be detected
+	link.URL.Host = strings.ToLower(link.URL.Host)
+	if r.BaseURL != nil {
+		link.Internal = link.Kind == FragmentLink || canonicalHost(link.URL.Host) == canonicalHost(r.BaseURL.Host)
+	} else {
+		link.Internal = link.Kind == FragmentLink || (link.Kind == PageLink && !reference.IsAbs())
+	}
+	return link, true
+}
@@ -0,0 +1,147 @@
+package soup
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+const linksPage = `<html><head>
+<link rel="stylesheet" href="/static/style.css">
+<script src="//cdn.example.net/app.js"></script>
+</head><body>
+<a href="python">Python</a>
+<a id="external" href="https://example.org/about">About</a>
+<a name="anchor">No link</a>
+<img src="/comics/python.png">
+<iframe src="embed.html"></iframe>
+<map><area href="#top"></map>
+<form action="search"></form>
+</body></html>`
+
+func TestLinks(t *testing.T) {
+	doc := HTMLParseWithURL(linksPage, "https://xkcd.com/353/")
+	var links []string
+	for _, link := range doc.Links() {
+		links = append(links, link.String())
+	}
+	expected := []string{
+		"https://xkcd.com/static/style.css",
+		"https://cdn.example.net/app.js",
+		"https://xkcd.com/353/python",
+		"https://example.org/about",
+		"https://xkcd.com/comics/python.png",
+		"https://xkcd.com/353/embed.html",
+		"https://xkcd.com/353/#top",
+		"https://xkcd.com/353/search",
+	}
+	if !reflect.DeepEqual(links, expected) {
+		t.Errorf("Expected %v, got %v", expected, links)
+	}
+}
+
+func TestAbsURL(t *testing.T) {
+	doc := HTMLParseWithURL(`<html><head><base href="/docs/"></head><body><img src="guide.png"></body></html>`, "https://example.com/index.html")
+	link, err := doc.Find("img").AbsURL("src")
+	if err != nil || link.String() != "https://example.com/docs/guide.png" {
+		t.Errorf("Expected the src resolved against <base href>, got %v (%v)", link, err)
+	}
+	if _, err := doc.Find("img").AbsURL("href"); err == nil {
+		t.Errorf("Expected an error for a missing attribute")
+	}
+
+	doc = HTMLParse(`<a href="/relative">Relative</a><img src="https://example.com/a.png">`)
+	if _, err := doc.Find("a").AbsURL("href"); err == nil {
+		t.Errorf("Expected an error for a relative URL without base URL")
+	}
+	if link, err := doc.Find("img").AbsURL("src"); err != nil || link.String() != "https://example.com/a.png" {
+		t.Errorf("Absolute URLs should be returned without base URL, got %v (%v)", link, err)
+	}
+}
+
+func TestBaseURLDefaults(t *testing.T) {
+	doc := HTMLParseWithURL(`<a href="/page">Page</a><form action="/login"></form>`, "https://example.com/")
+	if markdown := doc.Markdown(); markdown != "[Page](https://example.com/page)" {
+		t.Errorf("Markdown should resolve links against the document URL, got %q", markdown)
+	}
+	if forms := doc.Forms(); len(forms) != 1 || forms[0].Action != "https://example.com/login" {
+		t.Errorf("Forms should resolve actions against the document URL, got %+v", forms)
+	}
+}
+
+const extractPage = `<html><body>
+<nav><a href="/">Home</a> <a href="/blog/first">First
+	post</a></nav>
+<a href="/blog/first#comments">Comments</a>
+<a href="/blog/first">Duplicate</a>
+<a href="#top">Top</a>
+<a href="https://other.example.org/page" rel="nofollow ugc">Sponsor</a>
+<a href="https://Blog.Example.com/archive"><img src="/archive.png" alt="Archive"></a>
+<a href="mailto:info@example.com">Mail</a>
+<a href="tel:+123456">Call</a>
+<a href="javascript:void(0)">Menu</a>
+<a href="ftp://files.example.com/data.zip">Data</a>
+<map><area href="/map/north" alt="North"></map>
+</body></html>`
+
+func TestExtractLinks(t *testing.T) {
+	doc := HTMLParseWithURL(extractPage, "https://blog.example.com/index.html")
+	links := doc.ExtractLinks()
+	if len(links) != 11 {
+		t.Fatalf("Expected 11 distinct links, got %d: %v", len(links), links)
+	}
+	first := links[1]
+	if first.URL.String() != "https://blog.example.com/blog/first" || first.Text != "First post" || !first.Internal || first.Kind != PageLink {
+		t.Errorf("Unexpected link %+v", first)
+	}
+	if links[3].Kind != FragmentLink || !links[3].Internal || links[3].URL.String() != "https://blog.example.com/index.html#top" {
+		t.Errorf("Unexpected fragment link %+v", links[3])
+	}
+	sponsor := links[4]
+	if sponsor.Internal || !sponsor.NoFollow() || !reflect.DeepEqual(sponsor.Rel, []string{"nofollow", "ugc"}) {
+		t.Errorf("Unexpected external link %+v", sponsor)
+	}
+	if links[5].Text != "Archive" || !links[5].Internal {
+		t.Errorf("Image link should use the alt text and be internal, got %+v", links[5])
+	}
+	kinds := []LinkKind{links[6].Kind, links[7].Kind, links[8].Kind, links[9].Kind}
+	if !reflect.DeepEqual(kinds, []LinkKind{MailLink, PhoneLink, ScriptLink, OtherLink}) || links[6].Internal {
+		t.Errorf("Unexpected link kinds %v", kinds)
+	}
+	if links[10].Text != "North" {
+		t.Errorf("Area should use its alt text, got %+v", links[10])
+	}
+}
+
+func TestExtractLinksFilters(t *testing.T) {
+	doc := HTMLParseWithURL(extractPage, "https://blog.example.com/index.html")
+	urls := func(links []Link) []string {
+		var result []string
+		for _, link := range links {
+			result = append(result, link.URL.String())
+		}
+		return result
+	}
+	tests := []struct {
+		options  LinkOptions
+		expected []string
+	}{
+		{LinkOptions{PathPrefixes: []string{"/blog/"}}, []string{"https://blog.example.com/blog/first", "https://blog.example.com/blog/first#comments"}},
+		{LinkOptions{Hosts: []string{"example.org"}}, []string{"https://other.example.org/page"}},
+		{LinkOptions{Kinds: []LinkKind{PageLink}, ExternalOnly: true}, []string{"https://other.example.org/page"}},
+		{LinkOptions{Kinds: []LinkKind{PageLink}, InternalOnly: true, Pattern: regexp.MustCompile(`/(archive|map/)`)}, []string{"https://blog.example.com/archive", "https://blog.example.com/map/north"}},
+		{LinkOptions{Hosts: []string{"example.org", "example.com"}, SkipNoFollow: true, Pattern: regexp.MustCompile(`^https://[a-z.]+/page`)}, nil},
+	}
+	for _, test := range tests {
+		if result := urls(doc.ExtractLinks(test.options)); !reflect.DeepEqual(result, test.expected) {
+			t.Errorf("%+v: expected %v, got %v", test.options, test.expected, result)
+		}
+	}
+}
+
+func TestExtractLinksWithoutBase(t *testing.T) {
+	links := HTMLParse(`<a href="/relative">Relative</a><a href="https://example.com/">Absolute</a>`).ExtractLinks()
+	if len(links) != 2 || !links[0].Internal || links[1].Internal || links[0].URL.String() != "/relative" {
+		t.Errorf("Unexpected links %+v", links)
+	}
+}
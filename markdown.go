@@ -0,0 +1,432 @@
+package soup
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Markdown converts the subtree of the element into CommonMark/GFM.
+// Headings, paragraphs, emphasis, links, images, (nested) lists, blockquotes,
+// code blocks and tables are converted, everything else is reduced to its text.
+// Relative links and image sources are resolved against the passed base URL, or the base URL of the document.
+func (r Root) Markdown(baseURL ...string) string {
+	if r.Pointer == nil {
+		return ""
+	}
+	converter := markdownConverter{base: r.BaseURL}
+	if len(baseURL) == 1 && baseURL[0] != "" {
+		base, err := url.Parse(baseURL[0])
+		if err != nil {
+			if debug {
+				panic("Unable to parse the base URL " + baseURL[0])
+			}
+		} else {
+			converter.base = base
+		}
+	}
+	if isMarkdownBlock(r.Pointer) {
+		return strings.TrimSpace(converter.block(r.Pointer))
+	}
+	return escapeLineStarts(strings.TrimSpace(converter.inline(r.Pointer)))
+}
+
+// markdownConverter holds the state needed while converting a subtree
+type markdownConverter struct {
+	base *url.URL
+}
+
+// elements which are rendered as blocks of their own
+var markdownBlockElements = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true, "body": true,
+	"dd": true, "details": true, "dialog": true, "div": true, "dl": true, "dt": true,
+	"fieldset": true, "figcaption": true, "figure": true, "footer": true, "form": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"head": true, "header": true, "hr": true, "html": true, "li": true, "main": true,
+	"nav": true, "ol": true, "p": true, "pre": true, "section": true, "summary": true,
+	"table": true, "ul": true,
+	"noscript": true, "script": true, "style": true, "template": true, "title": true,
+}
+
+// elements which are not rendered at all
+var markdownSkippedElements = map[string]bool{
+	"head": true, "noscript": true, "script": true, "style": true, "template": true, "title": true,
+}
+
+// checks if the node is rendered as a block
+func isMarkdownBlock(n *html.Node) bool {
+	return n.Type == html.ElementNode && markdownBlockElements[n.Data]
+}
+
+// converts the children of the node into blocks, grouping inline content into paragraphs
+func (c *markdownConverter) blocks(n *html.Node) []string {
+	var blocks []string
+	var paragraph strings.Builder
+	flush := func() {
+		text := strings.TrimSpace(paragraph.String())
+		if text != "" {
+			blocks = append(blocks, escapeLineStarts(text))
+		}
+		paragraph.Reset()
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if isMarkdownBlock(child) {
+			flush()
+			if block := c.block(child); strings.TrimSpace(block) != "" {
+				blocks = append(blocks, block)
+			}
+		} else {
+			paragraph.WriteString(c.inline(child))
+		}
+	}
+	flush()
+	return blocks
+}
+
+// converts a block element
+func (c *markdownConverter) block(n *html.Node) string {
+	if markdownSkippedElements[n.Data] {
+		return ""
+	}
+	switch n.Data {
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		level, _ := strconv.Atoi(n.Data[1:])
+		text := strings.TrimSpace(strings.Replace(c.inlineChildren(n), "  \n", " ", -1))
+		if text == "" {
+			return ""
+		}
+		return strings.Repeat("#", level) + " " + text
+	case "p", "dt", "summary", "figcaption":
+		return escapeLineStarts(strings.TrimSpace(c.inlineChildren(n)))
+	case "hr":
+		return "---"
+	case "pre":
+		return c.codeBlock(n)
+	case "blockquote":
+		return prefixLines(strings.Join(c.blocks(n), "\n\n"), "> ", ">")
+	case "ul", "ol":
+		return c.list(n)
+	case "table":
+		return c.table(n)
+	}
+	return strings.Join(c.blocks(n), "\n\n")
+}
+
+// converts a list, nested lists are indented by the width of the parent marker
+func (c *markdownConverter) list(n *html.Node) string {
+	ordered := n.Data == "ol"
+	number := 1
+	if ordered {
+		for _, attribute := range n.Attr {
+			if attribute.Key == "start" {
+				if start, err := strconv.Atoi(attribute.Val); err == nil {
+					number = start
+				}
+			}
+		}
+	}
+	var items []string
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.ElementNode || child.Data != "li" {
+			continue
+		}
+		marker := "- "
+		if ordered {
+			marker = strconv.Itoa(number) + ". "
+			number++
+		}
+		separator := "\n"
+		for grandChild := child.FirstChild; grandChild != nil; grandChild = grandChild.NextSibling {
+			if grandChild.Type == html.ElementNode && grandChild.Data == "p" {
+				separator = "\n\n"
+				break
+			}
+		}
+		content := strings.Join(c.blocks(child), separator)
+		if content == "" {
+			items = append(items, strings.TrimRight(marker, " "))
+			continue
+		}
+		indent := strings.Repeat(" ", len(marker))
+		items = append(items, marker+prefixLines(content, indent, "")[len(indent):])
+	}
+	return strings.Join(items, "\n")
+}
+
+// converts a preformatted block into a fenced code block
+func (c *markdownConverter) codeBlock(n *html.Node) string {
+	language := ""
+	if code := n.FirstChild; code != nil && code.NextSibling == nil && code.Type == html.ElementNode && code.Data == "code" {
+		for _, attribute := range code.Attr {
+			if attribute.Key != "class" {
+				continue
+			}
+			for _, class := range strings.Fields(attribute.Val) {
+				if strings.HasPrefix(class, "language-") {
+					language = strings.TrimPrefix(class, "language-")
+				} else if strings.HasPrefix(class, "lang-") {
+					language = strings.TrimPrefix(class, "lang-")
+				}
+			}
+		}
+	}
+	text := strings.TrimSuffix(strings.TrimPrefix(rawText(n), "\n"), "\n")
+	fence := "```"
+	for strings.Contains(text, fence) {
+		fence += "`"
+	}
+	return fence + language + "\n" + text + "\n" + fence
+}
+
+// converts a table into a GFM table, the first row is used as header
+func (c *markdownConverter) table(n *html.Node) string {
+	var rows [][]string
+	columns := 0
+	var collect func(*html.Node)
+	collect = func(parent *html.Node) {
+		for child := parent.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type != html.ElementNode {
+				continue
+			}
+			switch child.Data {
+			case "thead", "tbody", "tfoot":
+				collect(child)
+			case "tr":
+				var row []string
+				for cell := child.FirstChild; cell != nil; cell = cell.NextSibling {
+					if cell.Type == html.ElementNode && (cell.Data == "td" || cell.Data == "th") {
+						text := strings.TrimSpace(strings.Replace(c.inlineChildren(cell), "  \n", " ", -1))
+						row = append(row, strings.Replace(text, "|", "\\|", -1))
+					}
+				}
+				if len(row) > columns {
+					columns = len(row)
+				}
+				rows = append(rows, row)
+			}
+		}
+	}
+	collect(n)
+	if len(rows) == 0 || columns == 0 {
+		return ""
+	}
+	lines := make([]string, 0, len(rows)+1)
+	for position, row := range rows {
+		for len(row) < columns {
+			row = append(row, "")
+		}
+		lines = append(lines, "| "+strings.Join(row, " | ")+" |")
+		if position == 0 {
+			separator := make([]string, columns)
+			for column := range separator {
+				separator[column] = "---"
+			}
+			lines = append(lines, "| "+strings.Join(separator, " | ")+" |")
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// converts all children of the node as inline content
+func (c *markdownConverter) inlineChildren(n *html.Node) string {
+	var buf strings.Builder
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		buf.WriteString(c.inline(child))
+	}
+	return buf.String()
+}
+
+// converts a node as inline content
+func (c *markdownConverter) inline(n *html.Node) string {
+	switch n.Type {
+	case html.TextNode:
+		return escapeMarkdown(collapseWhitespace(n.Data))
+	case html.ElementNode:
+	default:
+		return ""
+	}
+	if markdownSkippedElements[n.Data] {
+		return ""
+	}
+	switch n.Data {
+	case "br":
+		return "  \n"
+	case "em", "i":
+		return wrapInline(c.inlineChildren(n), "*")
+	case "strong", "b":
+		return wrapInline(c.inlineChildren(n), "**")
+	case "del", "s", "strike":
+		return wrapInline(c.inlineChildren(n), "~~")
+	case "code", "kbd", "samp":
+		text := collapseWhitespace(rawText(n))
+		fence := "`"
+		for strings.Contains(text, fence) {
+			fence += "`"
+		}
+		if strings.HasPrefix(text, "`") || strings.HasSuffix(text, "`") {
+			text = " " + text + " "
+		}
+		return fence + text + fence
+	case "a":
+		text := strings.TrimSpace(c.inlineChildren(n))
+		href, ok := attributeValue(n, "href")
+		if !ok || href == "" {
+			return text
+		}
+		return "[" + text + "](" + c.destination(href, attributeOrEmpty(n, "title")) + ")"
+	case "img":
+		src, ok := attributeValue(n, "src")
+		if !ok || src == "" {
+			return ""
+		}
+		alt := escapeMarkdown(collapseWhitespace(attributeOrEmpty(n, "alt")))
+		return "![" + alt + "](" + c.destination(src, attributeOrEmpty(n, "title")) + ")"
+	}
+	return c.inlineChildren(n)
+}
+
+// returns the link destination, resolved against the base URL if one is given
+func (c *markdownConverter) destination(link string, title string) string {
+	link = strings.TrimSpace(link)
+	if c.base != nil {
+		if reference, err := url.Parse(link); err == nil {
+			link = c.base.ResolveReference(reference).String()
+		}
+	}
+	if strings.ContainsAny(link, " ()") {
+		link = "<" + link + ">"
+	}
+	if title != "" {
+		link += ` "` + strings.Replace(title, `"`, `\"`, -1) + `"`
+	}
+	return link
+}
+
+// wraps the text with the given marker, keeping surrounding whitespace outside of the markers
+func wrapInline(text string, marker string) string {
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		return text
+	}
+	leading := text[:strings.Index(text, trimmed)]
+	trailing := text[len(leading)+len(trimmed):]
+	return leading + marker + trimmed + marker + trailing
+}
+
+// prefixes every line of the text, empty lines get the alternative prefix
+func prefixLines(text string, prefix string, emptyPrefix string) string {
+	lines := strings.Split(text, "\n")
+	for position, line := range lines {
+		if line == "" {
+			lines[position] = emptyPrefix
+		} else {
+			lines[position] = prefix + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// replaces all whitespace sequences with a single space
+func collapseWhitespace(text string) string {
+	var buf strings.Builder
+	space := false
+	for _, character := range text {
+		switch character {
+		case ' ', '\t', '\n', '\r', '\f':
+			if !space {
+				buf.WriteByte(' ')
+			}
+			space = true
+		default:
+			buf.WriteRune(character)
+			space = false
+		}
+	}
+	return buf.String()
+}
+
+// escapes the characters which would otherwise be interpreted as markdown,
+// including < and & which would start raw HTML or entities
+func escapeMarkdown(text string) string {
+	var buf strings.Builder
+	for _, character := range text {
+		switch character {
+		case '\\', '*', '_', '`', '[', ']', '<', '&':
+			buf.WriteByte('\\')
+		}
+		buf.WriteRune(character)
+	}
+	return buf.String()
+}
+
+// escapes the markers at the start of the lines of a paragraph which would otherwise begin another block:
+// headings, blockquotes, list items, thematic breaks, setext underlines and code fences
+func escapeLineStarts(text string) string {
+	lines := strings.Split(text, "\n")
+	for position, line := range lines {
+		content := strings.TrimLeft(line, " ")
+		indent := line[:len(line)-len(content)]
+		if content == "" {
+			continue
+		}
+		rest := content[1:]
+		followedBySpace := rest == "" || rest[0] == ' ' || rest[0] == '\t'
+		switch {
+		case content[0] == '#' || content[0] == '>':
+			content = "\\" + content
+		case content[0] == '-' && (followedBySpace || strings.Trim(content, "- ") == ""):
+			content = "\\" + content
+		case content[0] == '+' && followedBySpace:
+			content = "\\" + content
+		case content[0] == '=' && strings.Trim(content, "= ") == "":
+			content = "\\" + content
+		case strings.HasPrefix(content, "~~~"):
+			content = "\\" + content
+		default:
+			digits := 0
+			for digits < len(content) && digits < 9 && content[digits] >= '0' && content[digits] <= '9' {
+				digits++
+			}
+			if digits > 0 && digits < len(content) && (content[digits] == '.' || content[digits] == ')') &&
+				(digits+1 == len(content) || content[digits+1] == ' ' || content[digits+1] == '\t') {
+				content = content[:digits] + "\\" + content[digits:]
+			}
+		}
+		lines[position] = indent + content
+	}
+	return strings.Join(lines, "\n")
+}
+
+// returns all text of the subtree without any changes
+func rawText(n *html.Node) string {
+	var buf strings.Builder
+	var f func(*html.Node)
+	f = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			buf.WriteString(n.Data)
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			f(child)
+		}
+	}
+	f(n)
+	return buf.String()
+}
+
+// returns the value of the attribute and whether the node has it
+func attributeValue(n *html.Node, key string) (string, bool) {
+	for _, attribute := range n.Attr {
+		if attribute.Key == key {
+			return attribute.Val, true
+		}
+	}
+	return "", false
+}
+
+// returns the value of the attribute or an empty string
+func attributeOrEmpty(n *html.Node, key string) string {
+	value, _ := attributeValue(n, key)
+	return value
+}
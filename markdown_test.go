@@ -0,0 +1,98 @@
+package soup
+
+import (
+	"testing"
+)
+
+const markdownHTML = `
+<html>
+  <head><title>Article</title></head>
+  <body>
+    <article>
+      <h1>The <em>Title</em></h1>
+      <p>Some <strong>bold</strong> and <i>italic</i> text with a <a href="/docs/page.html" title="Docs">link</a>.</p>
+      <p>An image <img src="images/logo.png" alt="Logo"> and <code>inline code</code>.</p>
+      <ul>
+        <li>First</li>
+        <li>Second
+          <ol start="3">
+            <li>Nested three</li>
+            <li>Nested four</li>
+          </ol>
+        </li>
+      </ul>
+      <blockquote><p>Quoted</p><p>Twice</p></blockquote>
+      <pre><code class="language-go">func main() {
+	fmt.Println("hi")
+}</code></pre>
+      <table>
+        <thead><tr><th>Name</th><th>Value</th></tr></thead>
+        <tbody><tr><td>a|b</td><td>1</td></tr><tr><td>c</td></tr></tbody>
+      </table>
+    </article>
+  </body>
+</html>
+`
+
+var markdownDoc = HTMLParse(markdownHTML)
+
+func TestMarkdown(t *testing.T) {
+	expected := "# The *Title*\n\n" +
+		"Some **bold** and *italic* text with a [link](/docs/page.html \"Docs\").\n\n" +
+		"An image ![Logo](images/logo.png) and `inline code`.\n\n" +
+		"- First\n" +
+		"- Second\n" +
+		"  3. Nested three\n" +
+		"  4. Nested four\n\n" +
+		"> Quoted\n>\n> Twice\n\n" +
+		"```go\nfunc main() {\n\tfmt.Println(\"hi\")\n}\n```\n\n" +
+		"| Name | Value |\n| --- | --- |\n| a\\|b | 1 |\n| c |  |"
+	actual := markdownDoc.Find("article").Markdown()
+	if actual != expected {
+		t.Errorf("Wrong markdown:\n%s\nExpected:\n%s", actual, expected)
+	}
+}
+
+func TestMarkdownBaseURL(t *testing.T) {
+	actual := markdownDoc.Find("p").Markdown("https://example.com/blog/post")
+	expected := "Some **bold** and *italic* text with a [link](https://example.com/docs/page.html \"Docs\")."
+	if actual != expected {
+		t.Errorf("Wrong markdown: %s", actual)
+	}
+	actual = markdownDoc.Find("img").Markdown("https://example.com/blog/post")
+	if actual != "![Logo](https://example.com/blog/images/logo.png)" {
+		t.Errorf("Wrong markdown: %s", actual)
+	}
+}
+
+func TestMarkdownEmptyListItems(t *testing.T) {
+	actual := HTMLParse(`<ul><li></li><li> </li><li><script>x()</script></li><li>Item</li></ul><ol><li></li><li>Two</li></ol>`).Find("body").Markdown()
+	if actual != "-\n-\n-\n- Item\n\n1.\n2. Two" {
+		t.Errorf("Wrong markdown: %q", actual)
+	}
+}
+
+func TestMarkdownEscaping(t *testing.T) {
+	actual := HTMLParse(`<p>5 * 3 = [x]</p>`).Find("p").Markdown()
+	if actual != `5 \* 3 = \[x\]` {
+		t.Errorf("Wrong markdown: %s", actual)
+	}
+	// markers at the start of a line would begin another block
+	actual = HTMLParse(`<p># not a heading</p><p>1. not a list</p><p>- nope</p><p>+ nope</p><p>> no quote</p>` +
+		`<p>2) no<br>3.5 percent</p><p>Title<br>===</p><p>Title<br>---</p><p>~~~ no fence</p><div>-1 degrees</div>` +
+		`<ul><li>10. item</li></ul>`).Find("body").Markdown()
+	expected := "\\# not a heading\n\n1\\. not a list\n\n\\- nope\n\n\\+ nope\n\n\\> no quote\n\n" +
+		"2\\) no  \n3.5 percent\n\nTitle  \n\\===\n\nTitle  \n\\---\n\n\\~~~ no fence\n\n-1 degrees\n\n" +
+		"- 10\\. item"
+	if actual != expected {
+		t.Errorf("Wrong markdown: %q, expected %q", actual, expected)
+	}
+	if actual := HTMLParse(`<span># tag</span>`).Find("span").Markdown(); actual != `\# tag` {
+		t.Errorf("Wrong markdown: %s", actual)
+	}
+	// literal markup and entities in the text stay text
+	actual = HTMLParse(`<p>&lt;script&gt;alert(1)&lt;/script&gt; &amp;copy; AT&amp;T <img src="a.png" alt="<b>"></p>`).Find("p").Markdown()
+	if actual != `\<script>alert(1)\</script> \&copy; AT\&T ![\<b>](a.png)` {
+		t.Errorf("Wrong markdown: %s", actual)
+	}
+}
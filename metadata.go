@@ -0,0 +1,303 @@
+package soup
+
+import (
+	"encoding/json"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Metadata contains the structured data embedded in a page
+type Metadata struct {
+	Title       string
+	Description string
+	Language    string
+	// Canonical is the URL of the canonical link, resolved against the base URL of the document
+	Canonical string
+	// Meta maps the lowercase names of the meta elements to their content
+	Meta map[string]string
+	// OpenGraph maps the properties like og:title or article:author to their values, in document order
+	OpenGraph map[string][]string
+	// Twitter maps the Twitter Card properties like twitter:card to their values
+	Twitter map[string]string
+	// JSONLD contains the objects of the application/ld+json scripts, with @graph lists flattened
+	JSONLD []map[string]interface{}
+	// Microdata contains the top-level itemscope items, RDFa the top-level typeof items of RDFa Lite
+	Microdata []*MetadataItem
+	RDFa      []*MetadataItem
+}
+
+// MetadataItem is a Microdata or RDFa item
+type MetadataItem struct {
+	// Type contains the item types, RDFa types are prefixed with the vocabulary if they are relative
+	Type []string
+	// ID is the itemid of Microdata items or the resource of RDFa items
+	ID string
+	// Properties maps the property names to their values, which are strings or nested *MetadataItem
+	Properties map[string][]interface{}
+}
+
+// openGraphPrefixes are the prefixes of the OpenGraph properties
+var openGraphPrefixes = []string{"og:", "fb:", "article:", "book:", "profile:", "product:", "music:", "video:"}
+
+// Metadata extracts the structured data beneath the element: JSON-LD, Microdata, RDFa Lite,
+// OpenGraph and Twitter Card properties as well as the title, the meta elements and the canonical link
+func (r Root) Metadata() Metadata {
+	metadata := Metadata{
+		Meta:      make(map[string]string),
+		OpenGraph: make(map[string][]string),
+		Twitter:   make(map[string]string),
+	}
+	if r.Pointer == nil {
+		return metadata
+	}
+	var walk func(n *html.Node, vocab string)
+	walk = func(n *html.Node, vocab string) {
+		if n.Type == html.ElementNode {
+			if value, ok := attributeValue(n, "vocab"); ok {
+				vocab = value
+			}
+			r.collectMetadata(n, vocab, &metadata)
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child, vocab)
+		}
+	}
+	walk(r.Pointer, "")
+	metadata.Description = metadata.Meta["description"]
+	if metadata.Description == "" {
+		metadata.Description = firstValue(metadata.OpenGraph["og:description"])
+	}
+	if metadata.Title == "" {
+		metadata.Title = firstValue(metadata.OpenGraph["og:title"])
+	}
+	return metadata
+}
+
+// returns the first value or an empty string
+func firstValue(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// adds the metadata of the element, not looking at its children
+func (r Root) collectMetadata(n *html.Node, vocab string, metadata *Metadata) {
+	switch n.Data {
+	case "html":
+		if lang, ok := attributeValue(n, "lang"); ok && metadata.Language == "" {
+			metadata.Language = strings.TrimSpace(lang)
+		}
+	case "title":
+		if metadata.Title == "" {
+			metadata.Title = strings.TrimSpace(collapseWhitespace(rawText(n)))
+		}
+	case "meta":
+		content := attributeOrEmpty(n, "content")
+		name := strings.ToLower(strings.TrimSpace(attributeOrEmpty(n, "name")))
+		property := strings.ToLower(strings.TrimSpace(attributeOrEmpty(n, "property")))
+		if name != "" {
+			if _, ok := metadata.Meta[name]; !ok {
+				metadata.Meta[name] = content
+			}
+		}
+		for _, key := range []string{property, name} {
+			if strings.HasPrefix(key, "twitter:") {
+				if _, ok := metadata.Twitter[key]; !ok {
+					metadata.Twitter[key] = content
+				}
+				break
+			}
+			if hasAnyPrefix(key, openGraphPrefixes) {
+				metadata.OpenGraph[key] = append(metadata.OpenGraph[key], content)
+				break
+			}
+		}
+	case "link":
+		if metadata.Canonical == "" && hasToken(attributeOrEmpty(n, "rel"), "canonical") {
+			metadata.Canonical = r.resolve(attributeOrEmpty(n, "href"))
+		}
+	case "script":
+		if strings.EqualFold(strings.TrimSpace(attributeOrEmpty(n, "type")), "application/ld+json") {
+			metadata.JSONLD = append(metadata.JSONLD, parseJSONLD(rawText(n))...)
+		}
+	}
+	_, itemscope := attributeValue(n, "itemscope")
+	_, itemprop := attributeValue(n, "itemprop")
+	if itemscope && !itemprop {
+		metadata.Microdata = append(metadata.Microdata, r.microdataItem(n))
+	}
+	_, typeOf := attributeValue(n, "typeof")
+	_, property := attributeValue(n, "property")
+	if typeOf && !property {
+		metadata.RDFa = append(metadata.RDFa, r.rdfaItem(n, vocab))
+	}
+}
+
+// checks if the string starts with one of the prefixes
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// checks if the space separated list contains the token, ignoring case
+func hasToken(list string, token string) bool {
+	for _, field := range strings.Fields(list) {
+		if strings.EqualFold(field, token) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolves the URL against the base URL of the document, returning it unchanged if that isn't possible
+func (r Root) resolve(value string) string {
+	value = strings.TrimSpace(value)
+	if value == "" || r.BaseURL == nil {
+		return value
+	}
+	reference, err := r.BaseURL.Parse(value)
+	if err != nil {
+		return value
+	}
+	return reference.String()
+}
+
+// parses the content of a JSON-LD script, skipping invalid JSON
+func parseJSONLD(content string) []map[string]interface{} {
+	var value interface{}
+	if err := json.Unmarshal([]byte(content), &value); err != nil {
+		return nil
+	}
+	var objects []map[string]interface{}
+	var add func(value interface{})
+	add = func(value interface{}) {
+		switch value := value.(type) {
+		case []interface{}:
+			for _, element := range value {
+				add(element)
+			}
+		case map[string]interface{}:
+			if graph, ok := value["@graph"]; ok {
+				add(graph)
+				return
+			}
+			objects = append(objects, value)
+		}
+	}
+	add(value)
+	return objects
+}
+
+// returns the value of a Microdata or RDFa property element without item of its own
+func (r Root) propertyValue(n *html.Node, rdfa bool) string {
+	if rdfa {
+		if content, ok := attributeValue(n, "content"); ok {
+			return content
+		}
+		for _, attribute := range []string{"resource", "href", "src"} {
+			if value, ok := attributeValue(n, attribute); ok {
+				return r.resolve(value)
+			}
+		}
+	}
+	switch n.Data {
+	case "meta":
+		return attributeOrEmpty(n, "content")
+	case "audio", "embed", "iframe", "img", "source", "track", "video":
+		return r.resolve(attributeOrEmpty(n, "src"))
+	case "a", "area", "link":
+		return r.resolve(attributeOrEmpty(n, "href"))
+	case "object":
+		return r.resolve(attributeOrEmpty(n, "data"))
+	case "data", "meter":
+		return attributeOrEmpty(n, "value")
+	case "time":
+		if datetime, ok := attributeValue(n, "datetime"); ok {
+			return datetime
+		}
+	}
+	return strings.TrimSpace(collapseWhitespace(rawText(n)))
+}
+
+// builds the Microdata item of the itemscope element
+func (r Root) microdataItem(n *html.Node) *MetadataItem {
+	item := &MetadataItem{
+		Type:       strings.Fields(attributeOrEmpty(n, "itemtype")),
+		ID:         r.resolve(attributeOrEmpty(n, "itemid")),
+		Properties: make(map[string][]interface{}),
+	}
+	var walk func(parent *html.Node)
+	walk = func(parent *html.Node) {
+		for child := parent.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type != html.ElementNode {
+				continue
+			}
+			_, scope := attributeValue(child, "itemscope")
+			if names := strings.Fields(attributeOrEmpty(child, "itemprop")); len(names) > 0 {
+				var value interface{}
+				if scope {
+					value = r.microdataItem(child)
+				} else {
+					value = r.propertyValue(child, false)
+				}
+				for _, name := range names {
+					item.Properties[name] = append(item.Properties[name], value)
+				}
+			}
+			if !scope {
+				walk(child)
+			}
+		}
+	}
+	walk(n)
+	return item
+}
+
+// builds the RDFa item of the typeof element
+func (r Root) rdfaItem(n *html.Node, vocab string) *MetadataItem {
+	item := &MetadataItem{
+		ID:         r.resolve(attributeOrEmpty(n, "resource")),
+		Properties: make(map[string][]interface{}),
+	}
+	for _, name := range strings.Fields(attributeOrEmpty(n, "typeof")) {
+		if vocab != "" && !strings.Contains(name, ":") {
+			name = vocab + name
+		}
+		item.Type = append(item.Type, name)
+	}
+	var walk func(parent *html.Node, vocab string)
+	walk = func(parent *html.Node, vocab string) {
+		for child := parent.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type != html.ElementNode {
+				continue
+			}
+			childVocab := vocab
+			if value, ok := attributeValue(child, "vocab"); ok {
+				childVocab = value
+			}
+			_, typeOf := attributeValue(child, "typeof")
+			if names := strings.Fields(attributeOrEmpty(child, "property")); len(names) > 0 {
+				var value interface{}
+				if typeOf {
+					value = r.rdfaItem(child, childVocab)
+				} else {
+					value = r.propertyValue(child, true)
+				}
+				for _, name := range names {
+					item.Properties[name] = append(item.Properties[name], value)
+				}
+			}
+			if !typeOf {
+				walk(child, childVocab)
+			}
+		}
+	}
+	walk(n, vocab)
+	return item
+}
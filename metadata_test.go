@@ -0,0 +1,120 @@
+package soup
+
+import (
+	"reflect"
+	"testing"
+)
+
+const productPage = `<!DOCTYPE html>
+<html lang="en"><head>
+<title> Espresso   Machine | Shop </title>
+<meta name="Description" content="A fine espresso machine">
+<meta property="og:title" content="Espresso Machine">
+<meta property="og:image" content="https://shop.example.com/1.jpg">
+<meta property="og:image" content="https://shop.example.com/2.jpg">
+<meta property="product:price:amount" content="199.00">
+<meta name="twitter:card" content="summary_large_image">
+<link rel="canonical" href="/products/espresso">
+<script type="application/ld+json">
+{"@context": "https://schema.org", "@graph": [
+	{"@type": "Product", "name": "Espresso Machine", "offers": {"@type": "Offer", "price": "199.00"}},
+	{"@type": "BreadcrumbList"}
+]}
+</script>
+<script type="application/ld+json">[{"@type": "Organization", "name": "Shop"}]</script>
+<script type="application/ld+json">{invalid</script>
+</head><body>
+<div itemscope itemtype="https://schema.org/Product" itemid="#product">
+	<h1 itemprop="name">Espresso
+		Machine</h1>
+	<img itemprop="image" src="/img/espresso.jpg">
+	<div itemprop="offers" itemscope itemtype="https://schema.org/Offer">
+		<meta itemprop="priceCurrency" content="EUR">
+		<span itemprop="price">199.00</span>
+		<time itemprop="availabilityEnds" datetime="2025-01-01">New Year</time>
+	</div>
+	<a itemprop="url sameAs" href="/products/espresso">Link</a>
+</div>
+<div vocab="https://schema.org/" typeof="Person" resource="#me">
+	<span property="name">Ada</span>
+	<a property="url" href="https://ada.example.com">Site</a>
+	<div property="worksFor" typeof="Organization"><span property="name">Shop</span></div>
+</div>
+</body></html>`
+
+func TestMetadata(t *testing.T) {
+	metadata := HTMLParseWithURL(productPage, "https://shop.example.com/p?id=1").Metadata()
+	if metadata.Title != "Espresso Machine | Shop" || metadata.Description != "A fine espresso machine" || metadata.Language != "en" {
+		t.Errorf("Unexpected title, description or language %q %q %q", metadata.Title, metadata.Description, metadata.Language)
+	}
+	if metadata.Canonical != "https://shop.example.com/products/espresso" {
+		t.Errorf("Unexpected canonical link %q", metadata.Canonical)
+	}
+	expectedOpenGraph := map[string][]string{
+		"og:title":             {"Espresso Machine"},
+		"og:image":             {"https://shop.example.com/1.jpg", "https://shop.example.com/2.jpg"},
+		"product:price:amount": {"199.00"},
+	}
+	if !reflect.DeepEqual(metadata.OpenGraph, expectedOpenGraph) {
+		t.Errorf("Expected OpenGraph %v, got %v", expectedOpenGraph, metadata.OpenGraph)
+	}
+	if metadata.Twitter["twitter:card"] != "summary_large_image" || metadata.Meta["twitter:card"] != "summary_large_image" {
+		t.Errorf("Unexpected Twitter Card %v", metadata.Twitter)
+	}
+
+	if len(metadata.JSONLD) != 3 || metadata.JSONLD[0]["name"] != "Espresso Machine" || metadata.JSONLD[2]["@type"] != "Organization" {
+		t.Errorf("Unexpected JSON-LD %v", metadata.JSONLD)
+	}
+
+	if len(metadata.Microdata) != 1 {
+		t.Fatalf("Expected one Microdata item, got %d", len(metadata.Microdata))
+	}
+	product := metadata.Microdata[0]
+	if !reflect.DeepEqual(product.Type, []string{"https://schema.org/Product"}) || product.ID != "https://shop.example.com/p?id=1#product" {
+		t.Errorf("Unexpected item type %v or id %q", product.Type, product.ID)
+	}
+	expectedProperties := map[string][]interface{}{
+		"name":   {"Espresso Machine"},
+		"image":  {"https://shop.example.com/img/espresso.jpg"},
+		"url":    {"https://shop.example.com/products/espresso"},
+		"sameAs": {"https://shop.example.com/products/espresso"},
+		"offers": {&MetadataItem{
+			Type: []string{"https://schema.org/Offer"},
+			Properties: map[string][]interface{}{
+				"priceCurrency":    {"EUR"},
+				"price":            {"199.00"},
+				"availabilityEnds": {"2025-01-01"},
+			},
+		}},
+	}
+	if !reflect.DeepEqual(product.Properties, expectedProperties) {
+		t.Errorf("Expected properties %v, got %v", expectedProperties, product.Properties)
+	}
+
+	if len(metadata.RDFa) != 1 {
+		t.Fatalf("Expected one RDFa item, got %d", len(metadata.RDFa))
+	}
+	person := metadata.RDFa[0]
+	expectedPerson := &MetadataItem{
+		Type: []string{"https://schema.org/Person"},
+		ID:   "https://shop.example.com/p?id=1#me",
+		Properties: map[string][]interface{}{
+			"name": {"Ada"},
+			"url":  {"https://ada.example.com"},
+			"worksFor": {&MetadataItem{
+				Type:       []string{"https://schema.org/Organization"},
+				Properties: map[string][]interface{}{"name": {"Shop"}},
+			}},
+		},
+	}
+	if !reflect.DeepEqual(person, expectedPerson) {
+		t.Errorf("Expected RDFa item %+v, got %+v", expectedPerson, person)
+	}
+}
+
+func TestMetadataFallbacks(t *testing.T) {
+	metadata := HTMLParse(`<html><head><meta property="og:title" content="Only OG"><meta property="og:description" content="OG description"></head></html>`).Metadata()
+	if metadata.Title != "Only OG" || metadata.Description != "OG description" || metadata.Canonical != "" {
+		t.Errorf("Unexpected fallbacks %+v", metadata)
+	}
+}
@@ -0,0 +1,173 @@
+package soup
+
+import (
+	"errors"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// returns the error of a Root which can't be modified, nil if the Root is an element
+func (r Root) mutable() error {
+	if r.Error != nil {
+		return r.Error
+	}
+	if r.Pointer == nil || r.Pointer.Type != html.ElementNode {
+		if debug {
+			panic("Not an ElementNode")
+		}
+		return errors.New("not an element node")
+	}
+	return nil
+}
+
+// SetAttribute sets the value of the attribute, adding it if it doesn't exist.
+// Like the other methods modifying the tree it returns the element to allow chaining,
+// or a Root with the error if the element is missing or isn't an ElementNode.
+func (r Root) SetAttribute(name string, value string) Root {
+	if err := r.mutable(); err != nil {
+		return Root{nil, nil, "", err, nil}
+	}
+	for position := range r.Pointer.Attr {
+		if r.Pointer.Attr[position].Namespace == "" && r.Pointer.Attr[position].Key == name {
+			r.Pointer.Attr[position].Val = value
+			return r
+		}
+	}
+	r.Pointer.Attr = append(r.Pointer.Attr, html.Attribute{Key: name, Val: value})
+	return r
+}
+
+// RemoveAttribute removes the attribute, doing nothing if it doesn't exist
+func (r Root) RemoveAttribute(name string) Root {
+	if err := r.mutable(); err != nil {
+		return Root{nil, nil, "", err, nil}
+	}
+	attributes := r.Pointer.Attr[:0]
+	for _, attribute := range r.Pointer.Attr {
+		if attribute.Namespace != "" || attribute.Key != name {
+			attributes = append(attributes, attribute)
+		}
+	}
+	r.Pointer.Attr = attributes
+	return r
+}
+
+// sets the class attribute to the classes, removing it if there are none
+func (r Root) setClasses(classes []string) Root {
+	if len(classes) == 0 {
+		return r.RemoveAttribute("class")
+	}
+	return r.SetAttribute("class", strings.Join(classes, " "))
+}
+
+// AddClass adds the classes which the element doesn't have yet
+func (r Root) AddClass(names ...string) Root {
+	if err := r.mutable(); err != nil {
+		return Root{nil, nil, "", err, nil}
+	}
+	classes := strings.Fields(attributeOrEmpty(r.Pointer, "class"))
+	for _, name := range names {
+		for _, field := range strings.Fields(name) {
+			if !hasClass(classes, field) {
+				classes = append(classes, field)
+			}
+		}
+	}
+	return r.setClasses(classes)
+}
+
+// RemoveClass removes the classes, removing the class attribute if no class is left
+func (r Root) RemoveClass(names ...string) Root {
+	if err := r.mutable(); err != nil {
+		return Root{nil, nil, "", err, nil}
+	}
+	var removed []string
+	for _, name := range names {
+		removed = append(removed, strings.Fields(name)...)
+	}
+	var classes []string
+	for _, class := range strings.Fields(attributeOrEmpty(r.Pointer, "class")) {
+		if !hasClass(removed, class) {
+			classes = append(classes, class)
+		}
+	}
+	return r.setClasses(classes)
+}
+
+// ToggleClass removes the class if the element has it and adds it otherwise
+func (r Root) ToggleClass(name string) Root {
+	if err := r.mutable(); err != nil {
+		return Root{nil, nil, "", err, nil}
+	}
+	if hasClass(strings.Fields(attributeOrEmpty(r.Pointer, "class")), name) {
+		return r.RemoveClass(name)
+	}
+	return r.AddClass(name)
+}
+
+// checks if the list of classes contains the class
+func hasClass(classes []string, class string) bool {
+	for _, existing := range classes {
+		if existing == class {
+			return true
+		}
+	}
+	return false
+}
+
+// removes all children of the node
+func removeChildren(n *html.Node) {
+	for n.FirstChild != nil {
+		n.RemoveChild(n.FirstChild)
+	}
+}
+
+// SetText replaces the children of the element by a single text node
+func (r Root) SetText(text string) Root {
+	if err := r.mutable(); err != nil {
+		return Root{nil, nil, "", err, nil}
+	}
+	removeChildren(r.Pointer)
+	r.Pointer.AppendChild(&html.Node{Type: html.TextNode, Data: text})
+	return r
+}
+
+// SetInnerHTML replaces the children of the element by the parsed HTML fragment,
+// leaving the element unchanged if the fragment can't be parsed
+func (r Root) SetInnerHTML(fragment string) Root {
+	if err := r.mutable(); err != nil {
+		return Root{nil, nil, "", err, nil}
+	}
+	nodes, err := html.ParseFragment(strings.NewReader(fragment), r.Pointer)
+	if err != nil {
+		if debug {
+			panic("Unable to parse the HTML fragment")
+		}
+		return Root{nil, nil, "", errors.New("unable to parse the HTML fragment"), nil}
+	}
+	removeChildren(r.Pointer)
+	for _, n := range nodes {
+		r.Pointer.AppendChild(n)
+	}
+	return r
+}
+
+// Rename changes the tag name of the element, returning it with the new NodeValue.
+// Other Roots pointing to the element keep the old NodeValue, so find it again to match the new name.
+func (r Root) Rename(name string) Root {
+	if err := r.mutable(); err != nil {
+		return Root{nil, nil, "", err, nil}
+	}
+	if name == "" {
+		if debug {
+			panic("Tag name is empty")
+		}
+		return Root{nil, nil, "", errors.New("tag name is empty"), nil}
+	}
+	r.Pointer.Data = name
+	r.Pointer.DataAtom = atom.Lookup([]byte(name))
+	r.NodeValue = name
+	return r
+}
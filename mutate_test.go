@@ -0,0 +1,87 @@
+package soup
+
+import (
+	"bytes"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+// renders the element to compare it with the expected HTML
+func render(t *testing.T, r Root) string {
+	var buf bytes.Buffer
+	if err := html.Render(&buf, r.Pointer); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestSetAndRemoveAttribute(t *testing.T) {
+	doc := HTMLParse(`<div><a href="/old" onclick="track()">Link</a></div>`)
+	link := doc.Find("a").SetAttribute("href", "/new").SetAttribute("rel", "nofollow").RemoveAttribute("onclick").RemoveAttribute("missing")
+	if link.Error != nil {
+		t.Fatal(link.Error)
+	}
+	if rendered := render(t, doc.Find("a")); rendered != `<a href="/new" rel="nofollow">Link</a>` {
+		t.Errorf("Unexpected element %s", rendered)
+	}
+}
+
+func TestClasses(t *testing.T) {
+	doc := HTMLParse(`<p class="lead  intro">Text</p>`)
+	p := doc.Find("p").AddClass("intro", "highlight big").RemoveClass("lead").ToggleClass("big").ToggleClass("new")
+	if class := p.GetAttribute("class"); class != "intro highlight new" {
+		t.Errorf("Unexpected classes %q", class)
+	}
+	if doc.Find("p", "class", "highlight").Error != nil {
+		t.Errorf("Expected to find the element by its new class")
+	}
+	p.RemoveClass("intro", "highlight", "new")
+	if p.HasAttribute("class") {
+		t.Errorf("Expected the class attribute to be removed")
+	}
+}
+
+func TestSetTextAndInnerHTML(t *testing.T) {
+	doc := HTMLParse(`<div id="body"><p>Old <b>text</b></p></div>`)
+	p := doc.Find("p").SetText("<b> is escaped")
+	if rendered := render(t, p); rendered != `<p>&lt;b&gt; is escaped</p>` {
+		t.Errorf("Unexpected element %s", rendered)
+	}
+	body := doc.Find("div", "id", "body").SetInnerHTML(`<ul><li>One</li><li>Two</li></ul>text`)
+	if rendered := render(t, body); rendered != `<div id="body"><ul><li>One</li><li>Two</li></ul>text</div>` {
+		t.Errorf("Unexpected element %s", rendered)
+	}
+	if items := doc.FindAll("li"); len(items) != 2 || items[1].Text() != "Two" {
+		t.Errorf("Expected to find the parsed elements")
+	}
+}
+
+func TestRename(t *testing.T) {
+	doc := HTMLParse(`<div><b>Bold</b></div>`)
+	strong := doc.Find("b").Rename("strong")
+	if strong.NodeValue != "strong" || strong.Pointer.DataAtom.String() != "strong" {
+		t.Errorf("Expected the NodeValue to be in sync, got %q", strong.NodeValue)
+	}
+	if doc.Find("strong").Error != nil || doc.Find("b").Error == nil {
+		t.Errorf("Expected to find the element by its new name only")
+	}
+	if rendered := render(t, doc.Find("div")); rendered != `<div><strong>Bold</strong></div>` {
+		t.Errorf("Unexpected element %s", rendered)
+	}
+	if doc.Find("strong").Rename("").Error == nil {
+		t.Errorf("Expected an error for an empty tag name")
+	}
+}
+
+func TestMutationErrors(t *testing.T) {
+	doc := HTMLParse(`<div>Text</div>`)
+	missing := doc.Find("span").SetAttribute("id", "x").AddClass("a").SetText("text")
+	if missing.Error == nil || missing.Error.Error() != doc.Find("span").Error.Error() {
+		t.Errorf("Expected the error of Find to propagate, got %v", missing.Error)
+	}
+	text := doc.Find("div").Children(true)[0]
+	if text.SetAttribute("id", "x").Error == nil || text.SetInnerHTML("<b>x</b>").Error == nil {
+		t.Errorf("Expected an error for a text node")
+	}
+}
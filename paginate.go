@@ -0,0 +1,129 @@
+package soup
+
+import (
+	"errors"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// ErrStopPagination can be returned by the callback of Paginate to stop after the current page without an error
+var ErrStopPagination = errors.New("stop pagination")
+
+// Paginate fetches the start page and follows its next-page links using the default HTTP client,
+// see Session.Paginate
+func Paginate(startURL string, nextSelector string, fn func(page Root) error, maxPages ...int) error {
+	return globalSession(&http.Client{}).Paginate(startURL, nextSelector, fn, maxPages...)
+}
+
+// Pages returns an iterator over the start page and the pages reached by its next-page links using the default HTTP client,
+// see Session.Pages
+func Pages(startURL string, nextSelector string, maxPages ...int) iter.Seq2[Root, error] {
+	return globalSession(&http.Client{}).Pages(startURL, nextSelector, maxPages...)
+}
+
+// Paginate fetches the start page and follows its next-page links, passing every parsed page to the callback.
+// The next page is linked by the element matching nextSelector or, if there is none, by a link with rel="next".
+// Pagination ends at the last page, at a page which was already visited, after the optional maximum number of pages
+// or if the callback returns an error, which is returned unless it is ErrStopPagination.
+func (s *Session) Paginate(startURL string, nextSelector string, fn func(page Root) error, maxPages ...int) error {
+	for page, err := range s.Pages(startURL, nextSelector, maxPages...) {
+		if err != nil {
+			return err
+		}
+		if err := fn(page); err != nil {
+			if err == ErrStopPagination {
+				return nil
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// Pages returns an iterator over the start page and the pages reached by its next-page links like Paginate.
+// Errors are yielded once and end the iteration.
+func (s *Session) Pages(startURL string, nextSelector string, maxPages ...int) iter.Seq2[Root, error] {
+	return func(yield func(Root, error) bool) {
+		var next selector
+		if nextSelector != "" {
+			var err error
+			if next, err = compileSelector(nextSelector); err != nil {
+				if debug {
+					panic("Invalid selector " + nextSelector)
+				}
+				yield(Root{}, errors.New("invalid selector "+nextSelector))
+				return
+			}
+		}
+		limit := 0
+		if len(maxPages) == 1 {
+			limit = maxPages[0]
+		}
+		seen := make(map[string]bool)
+		for pageURL, count := startURL, 0; pageURL != "" && (limit <= 0 || count < limit); count++ {
+			target, err := url.Parse(pageURL)
+			if err != nil {
+				yield(Root{}, err)
+				return
+			}
+			seen[normalizeURL(target)] = true
+			resp, err := s.Do("GET", pageURL, nil)
+			if err != nil {
+				yield(Root{}, err)
+				return
+			}
+			if resp.StatusCode >= 400 {
+				if debug {
+					panic("Page " + pageURL + " returned status " + strconv.Itoa(resp.StatusCode))
+				}
+				yield(Root{}, errors.New("page "+pageURL+" returned status "+strconv.Itoa(resp.StatusCode)))
+				return
+			}
+			page := resp.HTML()
+			if final, err := url.Parse(resp.URL); err == nil {
+				seen[normalizeURL(final)] = true
+			}
+			if !yield(page, nil) {
+				return
+			}
+			pageURL = ""
+			if nextURL, ok := page.nextPage(next, nextSelector != ""); ok {
+				if target, err := url.Parse(nextURL); err == nil && !seen[normalizeURL(target)] {
+					pageURL = nextURL
+				}
+			}
+		}
+	}
+}
+
+// returns the URL of the next page, linked by the element matching the selector or by rel="next"
+func (r Root) nextPage(next selector, hasSelector bool) (string, bool) {
+	if r.Pointer == nil {
+		return "", false
+	}
+	if hasSelector {
+		if element, ok := r.selectOnce(next); ok {
+			if _, ok := attributeValue(element.Pointer, "href"); !ok {
+				// the selector may match a container of the link, like a list item
+				link, _ := compileSelector("a[href]")
+				element, ok = element.selectOnce(link)
+				if !ok {
+					return "", false
+				}
+			}
+			if link, err := element.absURL("href"); err == nil {
+				return link.String(), true
+			}
+			return "", false
+		}
+	}
+	relNext, _ := compileSelector("[rel~=next][href]")
+	if element, ok := r.selectOnce(relNext); ok {
+		if link, err := element.absURL("href"); err == nil {
+			return link.String(), true
+		}
+	}
+	return "", false
+}
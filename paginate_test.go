@@ -0,0 +1,85 @@
+package soup
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// listingServer serves five listing pages, the last one linking back to the first
+func listingServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var n int
+		fmt.Sscanf(r.URL.Query().Get("page"), "%d", &n)
+		next := n%5 + 1
+		switch {
+		case n == 0:
+			http.NotFound(w, r)
+		case n%2 == 1:
+			fmt.Fprintf(w, `<html><body><h1>%d</h1><ul class="pager"><li class="next"><a href="?page=%d">Next</a></li></ul></body></html>`, n, next)
+		default:
+			fmt.Fprintf(w, `<html><head><link rel="next" href="/list?page=%d"></head><body><h1>%d</h1></body></html>`, next, n)
+		}
+	}))
+}
+
+func TestPaginate(t *testing.T) {
+	ts := listingServer(t)
+	defer ts.Close()
+
+	var pages []string
+	err := NewSession().Paginate(ts.URL+"/list?page=1", "li.next", func(page Root) error {
+		pages = append(pages, page.Find("h1").Text())
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(pages, []string{"1", "2", "3", "4", "5"}) {
+		t.Errorf("Expected every page once, got %v", pages)
+	}
+
+	pages = nil
+	err = Paginate(ts.URL+"/list?page=2", "", func(page Root) error {
+		pages = append(pages, page.Find("h1").Text())
+		if len(pages) == 2 {
+			return ErrStopPagination
+		}
+		return nil
+	})
+	if err != nil || !reflect.DeepEqual(pages, []string{"2", "3"}) {
+		t.Errorf("Expected to follow rel=next and stop after two pages, got %v (%v)", pages, err)
+	}
+
+	failure := errors.New("failure")
+	if err := Paginate(ts.URL+"/list?page=1", "li.next", func(page Root) error { return failure }); err != failure {
+		t.Errorf("Expected the error of the callback, got %v", err)
+	}
+	if err := Paginate(ts.URL+"/list", "li.next", func(page Root) error { return nil }); err == nil {
+		t.Errorf("Expected an error for a missing page")
+	}
+}
+
+func TestPages(t *testing.T) {
+	ts := listingServer(t)
+	defer ts.Close()
+
+	var pages []string
+	for page, err := range Pages(ts.URL+"/list?page=2", ".pager .next", 3) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages = append(pages, page.Find("h1").Text())
+	}
+	if !reflect.DeepEqual(pages, []string{"2", "3", "4"}) {
+		t.Errorf("Expected three pages, got %v", pages)
+	}
+	for _, err := range Pages(ts.URL, "li[") {
+		if err == nil {
+			t.Errorf("Expected an error for an invalid selector")
+		}
+	}
+}
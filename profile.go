@@ -0,0 +1,130 @@
+package soup
+
+import (
+	"math/rand"
+	"net/http"
+)
+
+// HeaderProfile is a consistent set of headers sent by a browser
+type HeaderProfile map[string]string
+
+// ChromeDesktop contains the headers of Chrome on Windows navigating to a page
+var ChromeDesktop = HeaderProfile{
+	"User-Agent":                "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/131.0.0.0 Safari/537.36",
+	"Accept":                    "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,image/apng,*/*;q=0.8,application/signed-exchange;v=b3;q=0.7",
+	"Accept-Language":           "en-US,en;q=0.9",
+	"Accept-Encoding":           "gzip, deflate, br, zstd",
+	"Sec-Ch-Ua":                 `"Google Chrome";v="131", "Chromium";v="131", "Not_A Brand";v="24"`,
+	"Sec-Ch-Ua-Mobile":          "?0",
+	"Sec-Ch-Ua-Platform":        `"Windows"`,
+	"Sec-Fetch-Dest":            "document",
+	"Sec-Fetch-Mode":            "navigate",
+	"Sec-Fetch-Site":            "none",
+	"Sec-Fetch-User":            "?1",
+	"Upgrade-Insecure-Requests": "1",
+}
+
+// ChromeMobile contains the headers of Chrome on Android navigating to a page
+var ChromeMobile = HeaderProfile{
+	"User-Agent":                "Mozilla/5.0 (Linux; Android 10; K) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/131.0.0.0 Mobile Safari/537.36",
+	"Accept":                    "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,image/apng,*/*;q=0.8,application/signed-exchange;v=b3;q=0.7",
+	"Accept-Language":           "en-US,en;q=0.9",
+	"Accept-Encoding":           "gzip, deflate, br, zstd",
+	"Sec-Ch-Ua":                 `"Google Chrome";v="131", "Chromium";v="131", "Not_A Brand";v="24"`,
+	"Sec-Ch-Ua-Mobile":          "?1",
+	"Sec-Ch-Ua-Platform":        `"Android"`,
+	"Sec-Fetch-Dest":            "document",
+	"Sec-Fetch-Mode":            "navigate",
+	"Sec-Fetch-Site":            "none",
+	"Sec-Fetch-User":            "?1",
+	"Upgrade-Insecure-Requests": "1",
+}
+
+// FirefoxDesktop contains the headers of Firefox on Windows navigating to a page
+var FirefoxDesktop = HeaderProfile{
+	"User-Agent":                "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:133.0) Gecko/20100101 Firefox/133.0",
+	"Accept":                    "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8",
+	"Accept-Language":           "en-US,en;q=0.5",
+	"Accept-Encoding":           "gzip, deflate, br, zstd",
+	"Sec-Fetch-Dest":            "document",
+	"Sec-Fetch-Mode":            "navigate",
+	"Sec-Fetch-Site":            "none",
+	"Sec-Fetch-User":            "?1",
+	"Upgrade-Insecure-Requests": "1",
+}
+
+// SafariDesktop contains the headers of Safari on macOS navigating to a page
+var SafariDesktop = HeaderProfile{
+	"User-Agent":      "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/18.1 Safari/605.1.15",
+	"Accept":          "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8",
+	"Accept-Language": "en-US,en;q=0.9",
+	"Accept-Encoding": "gzip, deflate, br",
+	"Sec-Fetch-Dest":  "document",
+	"Sec-Fetch-Mode":  "navigate",
+	"Sec-Fetch-Site":  "none",
+}
+
+// SafariMobile contains the headers of Safari on iPhone navigating to a page
+var SafariMobile = HeaderProfile{
+	"User-Agent":      "Mozilla/5.0 (iPhone; CPU iPhone OS 18_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/18.1 Mobile/15E148 Safari/604.1",
+	"Accept":          "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8",
+	"Accept-Language": "en-US,en;q=0.9",
+	"Accept-Encoding": "gzip, deflate, br",
+	"Sec-Fetch-Dest":  "document",
+	"Sec-Fetch-Mode":  "navigate",
+	"Sec-Fetch-Site":  "none",
+}
+
+// HeaderProfiles contains the predefined profiles by name
+var HeaderProfiles = map[string]HeaderProfile{
+	"chrome-desktop":  ChromeDesktop,
+	"chrome-mobile":   ChromeMobile,
+	"firefox-desktop": FirefoxDesktop,
+	"safari-desktop":  SafariDesktop,
+	"safari-mobile":   SafariMobile,
+}
+
+// WithHeaders returns a copy of the session sending the headers in addition to those of the session,
+// to override headers for some requests without changing the session.
+// The copy shares the cookie jar, the cache and the throttling state with the session.
+func (s *Session) WithHeaders(headers map[string]string) *Session {
+	s.shared()
+	copied := *s
+	copied.Headers = make(map[string]string, len(s.Headers)+len(headers))
+	for name, value := range s.Headers {
+		copied.Headers[name] = value
+	}
+	for name, value := range headers {
+		copied.Headers[name] = value
+	}
+	return &copied
+}
+
+// returns the user agent of the next request from UserAgents, empty if there are none
+func (s *Session) nextUserAgent() string {
+	if len(s.UserAgents) == 0 {
+		return ""
+	}
+	if s.RandomUserAgent {
+		return s.UserAgents[rand.Intn(len(s.UserAgents))]
+	}
+	state := s.shared()
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	userAgent := s.UserAgents[state.userAgents%len(s.UserAgents)]
+	state.userAgents++
+	return userAgent
+}
+
+// sets the headers of the profile, the user agent and the headers of the session, in increasing precedence
+func (s *Session) setHeaders(header http.Header) {
+	for name, value := range s.Profile {
+		header.Set(name, value)
+	}
+	if userAgent := s.nextUserAgent(); userAgent != "" {
+		header.Set("User-Agent", userAgent)
+	}
+	for name, value := range s.Headers {
+		header.Set(name, value)
+	}
+}
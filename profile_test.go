@@ -0,0 +1,91 @@
+package soup
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHeaderProfile(t *testing.T) {
+	var received http.Header
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header
+	}))
+	defer ts.Close()
+
+	session := NewSession()
+	session.Profile = HeaderProfiles["firefox-desktop"]
+	session.Headers["Accept-Language"] = "de-DE"
+	if _, err := session.Get(ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	if received.Get("User-Agent") != FirefoxDesktop["User-Agent"] || received.Get("Sec-Fetch-Mode") != "navigate" {
+		t.Errorf("Profile headers not sent: %v", received)
+	}
+	if received.Get("Accept-Language") != "de-DE" {
+		t.Errorf("Session headers should override the profile, got %q", received.Get("Accept-Language"))
+	}
+	for name, profile := range HeaderProfiles {
+		if profile["User-Agent"] == "" || profile["Accept"] == "" || profile["Accept-Language"] == "" {
+			t.Errorf("Profile %s is incomplete", name)
+		}
+	}
+}
+
+func TestWithHeaders(t *testing.T) {
+	var referers []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		referers = append(referers, r.Header.Get("Referer"))
+		http.SetCookie(w, &http.Cookie{Name: "visited", Value: "yes"})
+	}))
+	defer ts.Close()
+
+	session := NewSession()
+	session.Headers["Referer"] = "https://example.com/"
+	if _, err := session.WithHeaders(map[string]string{"Referer": "https://example.org/"}).Get(ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := session.Get(ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	if referers[0] != "https://example.org/" || referers[1] != "https://example.com/" {
+		t.Errorf("Override should apply to a single request, got %v", referers)
+	}
+	if session.Headers["Referer"] != "https://example.com/" {
+		t.Errorf("Session headers should be unchanged, got %v", session.Headers)
+	}
+	if len(session.Jar.All()) != 1 {
+		t.Errorf("The copy should share the cookie jar")
+	}
+}
+
+func TestUserAgentRotation(t *testing.T) {
+	var userAgents []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgents = append(userAgents, r.Header.Get("User-Agent"))
+	}))
+	defer ts.Close()
+
+	session := NewSession()
+	session.Profile = ChromeDesktop
+	session.UserAgents = []string{"first", "second"}
+	for i := 0; i < 3; i++ {
+		if _, err := session.Get(ts.URL); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(userAgents) != 3 || userAgents[0] != "first" || userAgents[1] != "second" || userAgents[2] != "first" {
+		t.Errorf("User agents should be rotated, got %v", userAgents)
+	}
+
+	session.RandomUserAgent = true
+	userAgents = nil
+	for i := 0; i < 5; i++ {
+		session.Get(ts.URL)
+	}
+	for _, userAgent := range userAgents {
+		if userAgent != "first" && userAgent != "second" {
+			t.Errorf("Unexpected random user agent %q", userAgent)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package soup
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+)
+
+// standInProxy answers every proxied request itself with its name
+func standInProxy(name string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(name + " " + r.URL.String()))
+	}))
+}
+
+// standInSOCKS5 forwards the connections like a SOCKS5 proxy without authentication, counting them
+func standInSOCKS5(t *testing.T, connections *int32) net.Listener {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			atomic.AddInt32(connections, 1)
+			go func() {
+				defer conn.Close()
+				header := make([]byte, 2)
+				if _, err := io.ReadFull(conn, header); err != nil {
+					return
+				}
+				io.ReadFull(conn, make([]byte, header[1]))
+				conn.Write([]byte{5, 0})
+				request := make([]byte, 4)
+				if _, err := io.ReadFull(conn, request); err != nil {
+					return
+				}
+				var host string
+				switch request[3] {
+				case 1:
+					address := make([]byte, 4)
+					io.ReadFull(conn, address)
+					host = net.IP(address).String()
+				case 3:
+					length := make([]byte, 1)
+					io.ReadFull(conn, length)
+					name := make([]byte, length[0])
+					io.ReadFull(conn, name)
+					host = string(name)
+				default:
+					return
+				}
+				port := make([]byte, 2)
+				io.ReadFull(conn, port)
+				target, err := net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port)))))
+				if err != nil {
+					conn.Write([]byte{5, 5, 0, 1, 0, 0, 0, 0, 0, 0})
+					return
+				}
+				defer target.Close()
+				conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+				go io.Copy(target, conn)
+				io.Copy(conn, target)
+			}()
+		}
+	}()
+	return listener
+}
+
+func TestProxyRoundRobin(t *testing.T) {
+	first, second := standInProxy("first"), standInProxy("second")
+	defer first.Close()
+	defer second.Close()
+	pool, err := NewProxyPool(first.URL, "http://user:secret@"+second.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	session := NewSession()
+	session.Proxies = pool
+	var used []string
+	for i := 0; i < 3; i++ {
+		resp, err := session.Do("GET", "http://example.com/page", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		used = append(used, resp.Body[:len("first")+1])
+		if i == 1 && resp.Proxy != "http://user:xxxxx@"+second.Listener.Addr().String() {
+			t.Errorf("Unexpected reported proxy %q", resp.Proxy)
+		}
+	}
+	if used[0] != "first " || used[1] != "second" || used[2] != "first " {
+		t.Errorf("Proxies should be used round-robin, got %q", used)
+	}
+}
+
+func TestProxyRetirement(t *testing.T) {
+	alive := standInProxy("alive")
+	defer alive.Close()
+	dead := standInProxy("dead")
+	dead.Close()
+	pool, err := NewProxyPool(dead.URL, alive.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool.MaxFailures = 2
+	session := NewSession()
+	session.Proxies = pool
+	failures := 0
+	for i := 0; i < 6; i++ {
+		if _, err := session.Get("http://example.com/"); err != nil {
+			failures++
+		}
+	}
+	if failures != 2 {
+		t.Errorf("The dead proxy should fail twice before being retired, failed %d times", failures)
+	}
+	if active := pool.Active(); len(active) != 1 || active[0] != alive.URL {
+		t.Errorf("Only the alive proxy should be active, got %v", active)
+	}
+
+	pool.MaxFailures = 1
+	pool.report(1, true)
+	if _, err := session.Get("http://example.com/"); !errors.Is(err, ErrNoProxy) {
+		t.Errorf("Expected ErrNoProxy once every proxy is retired, got %v", err)
+	}
+}
+
+func TestProxySOCKS5(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("through socks"))
+	}))
+	defer ts.Close()
+	var connections int32
+	listener := standInSOCKS5(t, &connections)
+	defer listener.Close()
+
+	pool, err := NewProxyPool("socks5://" + listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	session := NewSession()
+	session.Proxies = pool
+	body, err := session.Get(ts.URL)
+	if err != nil || body != "through socks" {
+		t.Fatalf("Unexpected response %q (%v)", body, err)
+	}
+	if atomic.LoadInt32(&connections) == 0 {
+		t.Errorf("The request should have been sent through the SOCKS5 proxy")
+	}
+}
+
+func TestNewProxyPoolInvalid(t *testing.T) {
+	for _, proxy := range []string{"ftp://proxy:21", "not a url"} {
+		if _, err := NewProxyPool(proxy); err == nil {
+			t.Errorf("Expected an error for %q", proxy)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package soup
+
+import (
+	"context"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimit throttles the requests a session sends to a single host
+type RateLimit struct {
+	// RequestsPerSecond is the sustained rate of requests, 0 means unlimited
+	RequestsPerSecond float64
+	// Burst is the number of requests which may be sent at once before the rate applies, at least 1
+	Burst int
+	// MinDelay is the minimum time between the start of two requests
+	MinDelay time.Duration
+	// Jitter adds a random delay of up to the given duration before every request
+	Jitter time.Duration
+	// MaxConcurrent caps the number of requests in flight, 0 means unlimited
+	MaxConcurrent int
+}
+
+// hostLimiter keeps the throttling state of a single host
+type hostLimiter struct {
+	mu         sync.Mutex
+	cond       *sync.Cond
+	active     int
+	tokens     float64
+	updated    time.Time
+	next       time.Time
+	crawlDelay time.Duration
+}
+
+func newHostLimiter() *hostLimiter {
+	l := &hostLimiter{tokens: -1}
+	l.cond = sync.NewCond(&l.mu)
+	return l
+}
+
+// waits until the limit allows another request to be sent, returning the error of the context if it is done first
+func (l *hostLimiter) acquire(ctx context.Context, limit RateLimit) error {
+	l.mu.Lock()
+	if limit.MaxConcurrent > 0 && l.active >= limit.MaxConcurrent {
+		// the condition can't wait for the context, so the waiting requests are woken up once it is done
+		stop := context.AfterFunc(ctx, func() {
+			l.mu.Lock()
+			l.cond.Broadcast()
+			l.mu.Unlock()
+		})
+		defer stop()
+		for l.active >= limit.MaxConcurrent {
+			if err := ctx.Err(); err != nil {
+				// pass a slot which may have been released to this request on to the next one
+				l.cond.Signal()
+				l.mu.Unlock()
+				return err
+			}
+			l.cond.Wait()
+		}
+	}
+	l.active++
+
+	now := time.Now()
+	start := now
+	if l.next.After(start) {
+		start = l.next
+	}
+	if limit.RequestsPerSecond > 0 {
+		burst := float64(limit.Burst)
+		if burst < 1 {
+			burst = 1
+		}
+		if l.tokens < 0 {
+			// a new bucket starts full
+			l.tokens = burst
+			l.updated = start
+		}
+		l.tokens += start.Sub(l.updated).Seconds() * limit.RequestsPerSecond
+		if l.tokens > burst {
+			l.tokens = burst
+		}
+		l.updated = start
+		if l.tokens >= 1 {
+			l.tokens--
+		} else {
+			start = start.Add(time.Duration((1 - l.tokens) / limit.RequestsPerSecond * float64(time.Second)))
+			l.tokens = 0
+			l.updated = start
+		}
+	}
+	if limit.Jitter > 0 {
+		start = start.Add(time.Duration(rand.Int63n(int64(limit.Jitter) + 1)))
+	}
+	minDelay := limit.MinDelay
+	if l.crawlDelay > minDelay {
+		minDelay = l.crawlDelay
+	}
+	l.next = start.Add(minDelay)
+	l.mu.Unlock()
+
+	if wait := start.Sub(now); wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			l.release()
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// marks a request as finished
+func (l *hostLimiter) release() {
+	l.mu.Lock()
+	l.active--
+	l.cond.Signal()
+	l.mu.Unlock()
+}
+
+// returns the rate limit for the host, preferring the most specific entry of HostRateLimits
+func (s *Session) rateLimitFor(host string) *RateLimit {
+	for domain := host; domain != ""; {
+		if limit, ok := s.HostRateLimits[domain]; ok {
+			return limit
+		}
+		dot := strings.IndexByte(domain, '.')
+		if dot == -1 {
+			break
+		}
+		domain = domain[dot+1:]
+	}
+	return s.RateLimit
+}
+
+// returns the limiter of the host, creating it if needed
+func (s *Session) limiter(host string) *hostLimiter {
+	state := s.shared()
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	l, ok := state.limiters[host]
+	if !ok {
+		l = newHostLimiter()
+		state.limiters[host] = l
+	}
+	return l
+}
+
+// waits until a request may be sent to the host and returns the function to call once it is finished,
+// or the error of the context if it is done first
+func (s *Session) throttle(ctx context.Context, host string) (func(), error) {
+	host = canonicalHost(host)
+	limit := s.rateLimitFor(host)
+	if limit == nil {
+		limit = &RateLimit{}
+	}
+	l := s.limiter(host)
+	if err := l.acquire(ctx, *limit); err != nil {
+		return nil, err
+	}
+	return l.release, nil
+}
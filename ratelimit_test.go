@@ -0,0 +1,107 @@
+package soup
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRateLimitMinDelay(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	session := NewSession()
+	session.RateLimit = &RateLimit{MinDelay: 20 * time.Millisecond}
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if _, err := session.Get(ts.URL); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("Three requests should take at least 40ms, took %v", elapsed)
+	}
+}
+
+func TestRateLimitRequestsPerSecond(t *testing.T) {
+	l := newHostLimiter()
+	limit := RateLimit{RequestsPerSecond: 50, Burst: 2}
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		l.acquire(context.Background(), limit)
+		l.release()
+	}
+	// the burst allows two requests at once, the others wait 20ms each
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond || elapsed > time.Second {
+		t.Errorf("Four requests should take about 40ms, took %v", elapsed)
+	}
+}
+
+func TestRateLimitMaxConcurrent(t *testing.T) {
+	var active, maximum int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := atomic.AddInt32(&active, 1)
+		for {
+			previous := atomic.LoadInt32(&maximum)
+			if current <= previous || atomic.CompareAndSwapInt32(&maximum, previous, current) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&active, -1)
+	}))
+	defer ts.Close()
+
+	session := NewSession()
+	session.HostRateLimits = map[string]*RateLimit{"127.0.0.1": {MaxConcurrent: 2}}
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			session.Get(ts.URL)
+		}()
+	}
+	wg.Wait()
+	if maximum > 2 {
+		t.Errorf("At most 2 concurrent requests expected, got %d", maximum)
+	}
+}
+
+func TestRateLimitFor(t *testing.T) {
+	defaultLimit := &RateLimit{RequestsPerSecond: 1}
+	domainLimit := &RateLimit{RequestsPerSecond: 2}
+	session := &Session{RateLimit: defaultLimit, HostRateLimits: map[string]*RateLimit{"example.com": domainLimit}}
+	if session.rateLimitFor("www.example.com") != domainLimit || session.rateLimitFor("example.com") != domainLimit {
+		t.Errorf("Domain limit should apply to the domain and its subdomains")
+	}
+	if session.rateLimitFor("example.org") != defaultLimit {
+		t.Errorf("Default limit should apply to other hosts")
+	}
+}
+
+func TestRateLimitCancel(t *testing.T) {
+	l := newHostLimiter()
+	limit := RateLimit{MinDelay: time.Minute, MaxConcurrent: 1}
+	if err := l.acquire(context.Background(), limit); err != nil {
+		t.Fatal(err)
+	}
+	// waiting for the running request
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := l.acquire(ctx, limit); err != context.DeadlineExceeded {
+		t.Errorf("Expected the error of the context, got %v", err)
+	}
+	l.release()
+	// waiting for the delay, which frees the slot again
+	if err := l.acquire(ctx, limit); err != context.DeadlineExceeded {
+		t.Errorf("Expected the error of the context, got %v", err)
+	}
+	if l.active != 0 {
+		t.Errorf("Cancelled requests should not keep a slot, %d active", l.active)
+	}
+}
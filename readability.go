@@ -0,0 +1,344 @@
+package soup
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// Article is the main content of a page found by Article()
+type Article struct {
+	// Content is the element containing the article body
+	Content   Root
+	Title     string
+	Byline    string
+	Published time.Time
+	// Image is the URL of the lead image, resolved against the base URL of the document
+	Image   string
+	Excerpt string
+	Error   error
+}
+
+var (
+	// unlikelyCandidates matches the class and id of elements which hardly ever contain the main content
+	unlikelyCandidates = regexp.MustCompile(`(?i)-ad-|ai2html|banner|breadcrumbs|combx|comment|community|cover-wrap|disqus|extra|footer|gdpr|header|legends|menu|related|remark|replies|rss|shoutbox|sidebar|skyscraper|social|sponsor|supplemental|ad-break|agegate|pagination|pager|popup|yom-remote|newsletter|share|promo`)
+	// maybeCandidates rescues elements matching unlikelyCandidates which may contain the main content
+	maybeCandidates = regexp.MustCompile(`(?i)and|article|body|column|content|main|shadow`)
+	// positiveHints and negativeHints adjust the score of elements by their class and id
+	positiveHints = regexp.MustCompile(`(?i)article|body|content|entry|hentry|h-entry|main|page|pagination|post|text|blog|story`)
+	negativeHints = regexp.MustCompile(`(?i)-ad-|hidden|^hid$| hid$| hid |^hid |banner|combx|comment|com-|contact|foot|footer|footnote|gdpr|masthead|media|meta|outbrain|promo|related|scroll|share|shoutbox|sidebar|skyscraper|sponsor|shopping|tags|tool|widget`)
+	// bylineHints matches the class, id and rel of elements naming the author
+	bylineHints = regexp.MustCompile(`(?i)byline|author|dateline|writtenby|p-author`)
+	// titleSeparators split the name of the site from the title of the page
+	titleSeparators = regexp.MustCompile(`\s+[|\-–—:»]\s+`)
+)
+
+// skippedElements are never part of the main content
+var skippedElements = map[string]bool{
+	"script": true, "style": true, "noscript": true, "nav": true, "aside": true,
+	"footer": true, "header": true, "form": true, "iframe": true, "svg": true,
+	"button": true, "select": true, "textarea": true, "input": true,
+}
+
+// returns the class and id of the element
+func classAndID(n *html.Node) string {
+	return attributeOrEmpty(n, "class") + " " + attributeOrEmpty(n, "id")
+}
+
+// returns the score of the element by its class and id
+func classWeight(n *html.Node) float64 {
+	weight := 0.0
+	for _, value := range []string{attributeOrEmpty(n, "class"), attributeOrEmpty(n, "id")} {
+		if value == "" {
+			continue
+		}
+		if negativeHints.MatchString(value) {
+			weight -= 25
+		}
+		if positiveHints.MatchString(value) {
+			weight += 25
+		}
+	}
+	return weight
+}
+
+// returns the initial score of a candidate by its tag and class
+func initialScore(n *html.Node) float64 {
+	score := classWeight(n)
+	switch n.Data {
+	case "article":
+		score += 10
+	case "div", "main", "section":
+		score += 5
+	case "pre", "td", "blockquote":
+		score += 3
+	case "address", "ol", "ul", "dl", "dd", "dt", "li", "form":
+		score -= 3
+	case "h1", "h2", "h3", "h4", "h5", "h6", "th":
+		score -= 5
+	}
+	return score
+}
+
+// returns the share of the text of the element which is inside of links
+func linkDensity(n *html.Node) float64 {
+	textLength := len(strings.TrimSpace(collapseWhitespace(rawText(n))))
+	if textLength == 0 {
+		return 0
+	}
+	linkLength := 0
+	var f func(*html.Node)
+	f = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "a" {
+			linkLength += len(strings.TrimSpace(collapseWhitespace(rawText(n))))
+			return
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			f(child)
+		}
+	}
+	f(n)
+	return float64(linkLength) / float64(textLength)
+}
+
+// Article extracts the main content of the page like Readability: paragraphs are scored by their length and commas,
+// passing their score to their ancestors, which are weighted by their tag, their class and id and their link density.
+// The title, byline, publish date, lead image and excerpt are taken from the metadata of the page if possible.
+func (r Root) Article() Article {
+	if r.Pointer == nil {
+		if debug {
+			panic("Element is empty")
+		}
+		return Article{Error: errors.New("element is empty")}
+	}
+	scores := make(map[*html.Node]float64)
+	var candidates []*html.Node
+	addScore := func(n *html.Node, score float64) {
+		if _, ok := scores[n]; !ok {
+			scores[n] = initialScore(n)
+			candidates = append(candidates, n)
+		}
+		scores[n] += score
+	}
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type != html.ElementNode {
+			return
+		}
+		if skippedElements[n.Data] {
+			return
+		}
+		if hints := classAndID(n); n.Data != "body" && n.Data != "article" && unlikelyCandidates.MatchString(hints) && !maybeCandidates.MatchString(hints) {
+			return
+		}
+		switch n.Data {
+		case "p", "pre", "td", "blockquote":
+			text := strings.TrimSpace(collapseWhitespace(rawText(n)))
+			if len(text) >= 25 {
+				score := 1 + float64(strings.Count(text, ",")+strings.Count(text, "，"))
+				if bonus := float64(len(text)) / 100; bonus < 3 {
+					score += bonus
+				} else {
+					score += 3
+				}
+				level := 0
+				for ancestor := n.Parent; ancestor != nil && ancestor.Type == html.ElementNode && level < 3; ancestor = ancestor.Parent {
+					switch level {
+					case 0:
+						addScore(ancestor, score)
+					case 1:
+						addScore(ancestor, score/2)
+					default:
+						addScore(ancestor, score/float64(level*3))
+					}
+					level++
+				}
+			}
+			return
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(r.Pointer)
+
+	var top *html.Node
+	topScore := 0.0
+	for _, candidate := range candidates {
+		score := scores[candidate] * (1 - linkDensity(candidate))
+		if top == nil || score > topScore {
+			top, topScore = candidate, score
+		}
+	}
+	if top == nil {
+		if debug {
+			panic("No main content found")
+		}
+		return Article{Error: errors.New("no main content found")}
+	}
+
+	metadata := r.Metadata()
+	article := Article{Content: r.rootOf(top)}
+	article.Title = r.articleTitle(metadata)
+	article.Byline = r.byline(metadata)
+	article.Published = published(metadata, top)
+	article.Image = r.leadImage(metadata, top)
+	article.Excerpt = metadata.Description
+	if article.Excerpt == "" {
+		if paragraph, ok := article.Content.findOnce([]string{"p"}, false, false); ok {
+			article.Excerpt = strings.TrimSpace(collapseWhitespace(rawText(paragraph.Pointer)))
+		}
+	}
+	return article
+}
+
+// returns the Root of the node beneath the element, keeping the chain of parents
+func (r Root) rootOf(n *html.Node) Root {
+	var path []*html.Node
+	for ancestor := n; ancestor != nil && ancestor != r.Pointer; ancestor = ancestor.Parent {
+		path = append(path, ancestor)
+	}
+	current := r
+	for position := len(path) - 1; position >= 0; position-- {
+		parent := current
+		current = Root{&parent, path[position], path[position].Data, nil, r.BaseURL}
+	}
+	return current
+}
+
+// returns the title of the article: the headline of its JSON-LD, a h1 contained in the title or the title without the site name
+func (r Root) articleTitle(metadata Metadata) string {
+	for _, object := range metadata.JSONLD {
+		if headline, ok := object["headline"].(string); ok && headline != "" {
+			return strings.TrimSpace(headline)
+		}
+	}
+	title := metadata.Title
+	if og := firstValue(metadata.OpenGraph["og:title"]); og != "" {
+		title = og
+	}
+	if headings := r.findAll([]string{"h1"}, true, false); len(headings) == 1 {
+		heading := strings.TrimSpace(collapseWhitespace(rawText(headings[0].Pointer)))
+		if heading != "" && (title == "" || strings.Contains(title, heading)) {
+			return heading
+		}
+	}
+	if separators := titleSeparators.FindAllStringIndex(title, -1); len(separators) > 0 {
+		last := separators[len(separators)-1]
+		if candidate := title[:last[0]]; len(strings.Fields(candidate)) >= 3 {
+			return candidate
+		}
+	}
+	return title
+}
+
+// returns the name of an author given by a JSON-LD object
+func jsonLDAuthor(value interface{}) string {
+	switch value := value.(type) {
+	case string:
+		return value
+	case map[string]interface{}:
+		name, _ := value["name"].(string)
+		return name
+	case []interface{}:
+		var names []string
+		for _, author := range value {
+			if name := jsonLDAuthor(author); name != "" {
+				names = append(names, name)
+			}
+		}
+		return strings.Join(names, ", ")
+	}
+	return ""
+}
+
+// returns the author of the article from the metadata or an element marked as byline
+func (r Root) byline(metadata Metadata) string {
+	for _, object := range metadata.JSONLD {
+		if author := jsonLDAuthor(object["author"]); author != "" {
+			return strings.TrimSpace(author)
+		}
+	}
+	if author := metadata.Meta["author"]; author != "" {
+		return author
+	}
+	if author := firstValue(metadata.OpenGraph["article:author"]); author != "" && !strings.Contains(author, "://") {
+		return author
+	}
+	var byline string
+	var f func(n *html.Node)
+	f = func(n *html.Node) {
+		if byline != "" || n.Type != html.ElementNode {
+			return
+		}
+		if bylineHints.MatchString(classAndID(n)+" "+attributeOrEmpty(n, "rel")+" "+attributeOrEmpty(n, "itemprop")) && !unlikelyCandidates.MatchString(classAndID(n)) {
+			if text := strings.TrimSpace(collapseWhitespace(rawText(n))); text != "" && len(text) < 100 {
+				byline = text
+				return
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			f(child)
+		}
+	}
+	f(r.Pointer)
+	return byline
+}
+
+// returns the publish date of the article from the metadata or the first time element of the content
+func published(metadata Metadata, content *html.Node) time.Time {
+	var values []string
+	for _, object := range metadata.JSONLD {
+		if date, ok := object["datePublished"].(string); ok {
+			values = append(values, date)
+		}
+	}
+	values = append(values, metadata.OpenGraph["article:published_time"]...)
+	for _, name := range []string{"date", "pubdate", "publishdate", "publish-date", "article:published_time", "dc.date"} {
+		if value, ok := metadata.Meta[name]; ok {
+			values = append(values, value)
+		}
+	}
+	if element, ok := (Root{nil, content, content.Data, nil, nil}).findOnce([]string{"time"}, true, false); ok {
+		values = append(values, attributeOrEmpty(element.Pointer, "datetime"))
+	}
+	for _, value := range values {
+		if date := parseFeedTime(value); !date.IsZero() {
+			return date
+		}
+	}
+	return time.Time{}
+}
+
+// returns the lead image of the article from the metadata or the first image of the content
+func (r Root) leadImage(metadata Metadata, content *html.Node) string {
+	if image := firstValue(metadata.OpenGraph["og:image"]); image != "" {
+		return r.resolve(image)
+	}
+	if image := metadata.Twitter["twitter:image"]; image != "" {
+		return r.resolve(image)
+	}
+	for _, object := range metadata.JSONLD {
+		switch image := object["image"].(type) {
+		case string:
+			return r.resolve(image)
+		case map[string]interface{}:
+			if url, ok := image["url"].(string); ok {
+				return r.resolve(url)
+			}
+		case []interface{}:
+			if len(image) > 0 {
+				if url, ok := image[0].(string); ok {
+					return r.resolve(url)
+				}
+			}
+		}
+	}
+	if image, ok := (Root{nil, content, content.Data, nil, nil}).findOnce([]string{"img"}, false, false); ok {
+		return r.resolve(attributeOrEmpty(image.Pointer, "src"))
+	}
+	return ""
+}
@@ -0,0 +1,100 @@
+package soup
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const newsPage = `<!DOCTYPE html>
+<html><head>
+<title>Rivers rise after a week of rain | Daily News</title>
+<meta name="author" content="Jane Doe">
+<meta property="article:published_time" content="2024-03-05T08:30:00Z">
+<meta property="og:image" content="/img/river.jpg">
+</head><body>
+<header><nav><a href="/">Home</a> <a href="/world">World</a> <a href="/sports">Sports</a></nav></header>
+<div class="sidebar">
+	<p>Most read: a list of stories, all of them popular, which you should read now.</p>
+</div>
+<div id="main-content">
+	<h1>Rivers rise after a week of rain</h1>
+	<div class="story-body">
+		<p>Rivers across the region rose to record levels on Tuesday, after a week of heavy rain, strong winds and thunderstorms.</p>
+		<p>Officials closed several bridges, evacuated low-lying neighbourhoods and opened emergency shelters in schools, halls and churches.</p>
+		<p>Forecasters expect the rain to ease by the weekend, although the water will take days to recede, they said.</p>
+		<img src="/img/inline.jpg">
+	</div>
+	<div class="comments">
+		<p>Great article, thanks for writing it, I really enjoyed reading this one.</p>
+		<p>I disagree with this, the rain was not that bad here, we only got a little.</p>
+	</div>
+</div>
+<div class="related-links">
+	<p><a href="/a">Another story about the weather in the region, with pictures</a></p>
+</div>
+<footer><p>Copyright Daily News, all rights reserved, 2024, and so on.</p></footer>
+</body></html>`
+
+func TestArticle(t *testing.T) {
+	article := HTMLParseWithURL(newsPage, "https://news.example.com/rivers").Article()
+	if article.Error != nil {
+		t.Fatalf("Unexpected error %v", article.Error)
+	}
+	if article.Content.Attributes()["class"] != "story-body" {
+		t.Errorf("Expected the story body as content, got %s %v", article.Content.NodeValue, article.Content.Attributes())
+	}
+	if article.Content.FindParent().Attributes()["id"] != "main-content" {
+		t.Errorf("Expected the content to keep its parents")
+	}
+	if text := article.Content.FullText(); strings.Contains(text, "Great article") || !strings.Contains(text, "record levels") {
+		t.Errorf("Unexpected content %q", text)
+	}
+	if article.Title != "Rivers rise after a week of rain" || article.Byline != "Jane Doe" {
+		t.Errorf("Unexpected title or byline %q %q", article.Title, article.Byline)
+	}
+	if !article.Published.Equal(time.Date(2024, 3, 5, 8, 30, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected publish date %v", article.Published)
+	}
+	if article.Image != "https://news.example.com/img/river.jpg" {
+		t.Errorf("Unexpected lead image %q", article.Image)
+	}
+	if !strings.HasPrefix(article.Excerpt, "Rivers across the region") {
+		t.Errorf("Unexpected excerpt %q", article.Excerpt)
+	}
+}
+
+func TestArticleWithoutMetadata(t *testing.T) {
+	page := `<html><head><title>A long headline about the election results - The Paper</title></head><body>
+<div class="post"><span class="byline">By John Smith</span>
+<p>Voters went to the polls on Sunday, in large numbers, to elect a new parliament and president.</p>
+<p>The results, which were announced on Monday, showed a narrow lead for the opposition.</p>
+<time datetime="2023-11-12">Sunday</time><img src="photo.png"></div>
+<ul class="links"><li><a href="/1">One link with a long text, a very long text indeed</a></li></ul>
+</body></html>`
+	article := HTMLParseWithURL(page, "https://paper.example.com/news/election").Article()
+	if article.Error != nil {
+		t.Fatalf("Unexpected error %v", article.Error)
+	}
+	if article.Title != "A long headline about the election results" {
+		t.Errorf("Unexpected title %q", article.Title)
+	}
+	if article.Byline != "By John Smith" {
+		t.Errorf("Unexpected byline %q", article.Byline)
+	}
+	if !article.Published.Equal(time.Date(2023, 11, 12, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected publish date %v", article.Published)
+	}
+	if article.Image != "https://paper.example.com/news/photo.png" {
+		t.Errorf("Unexpected lead image %q", article.Image)
+	}
+	if !strings.HasPrefix(article.Excerpt, "Voters went") {
+		t.Errorf("Unexpected excerpt %q", article.Excerpt)
+	}
+}
+
+func TestArticleNotFound(t *testing.T) {
+	if article := HTMLParse("<html><body><nav><p>Only navigation, nothing else to read here.</p></nav></body></html>").Article(); article.Error == nil {
+		t.Errorf("Expected an error for a page without content")
+	}
+}
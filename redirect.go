@@ -0,0 +1,48 @@
+package soup
+
+import (
+	"net/http"
+	"strings"
+)
+
+// RedirectPolicy controls which redirects a session follows.
+// A redirect which isn't followed is returned as the response, with its status code and Location header.
+type RedirectPolicy struct {
+	// MaxRedirects caps the number of redirects followed, 0 follows up to 10 redirects and a negative value none
+	MaxRedirects int
+	// SameHost refuses redirects to another host or port than the one of the request
+	SameHost bool
+}
+
+// Redirect is a single redirect a response went through
+type Redirect struct {
+	URL        string
+	StatusCode int
+}
+
+// decides if the redirect to the request is followed, via contains the previous requests
+func (p *RedirectPolicy) check(req *http.Request, via []*http.Request) error {
+	maxRedirects := p.MaxRedirects
+	if maxRedirects == 0 {
+		maxRedirects = 10
+	}
+	if len(via) > maxRedirects {
+		return http.ErrUseLastResponse
+	}
+	if p.SameHost && !strings.EqualFold(req.URL.Host, via[0].URL.Host) {
+		return http.ErrUseLastResponse
+	}
+	return nil
+}
+
+// returns the redirects which led to the response, starting with the original request
+func redirectChain(resp *http.Response) []Redirect {
+	var chain []Redirect
+	if resp.Request == nil {
+		return nil
+	}
+	for previous := resp.Request.Response; previous != nil && previous.Request != nil; previous = previous.Request.Response {
+		chain = append([]Redirect{{previous.Request.URL.String(), previous.StatusCode}}, chain...)
+	}
+	return chain
+}
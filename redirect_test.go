@@ -0,0 +1,63 @@
+package soup
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestRedirectChain(t *testing.T) {
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("other host"))
+	}))
+	defer other.Close()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/product":
+			http.Redirect(w, r, "/moved", http.StatusMovedPermanently)
+		case "/moved":
+			http.Redirect(w, r, "/unavailable", http.StatusFound)
+		case "/external":
+			http.Redirect(w, r, other.URL, http.StatusFound)
+		default:
+			w.Write([]byte("not available"))
+		}
+	}))
+	defer ts.Close()
+
+	session := NewSession()
+	resp, err := session.Do("GET", ts.URL+"/product", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Redirect{{ts.URL + "/product", http.StatusMovedPermanently}, {ts.URL + "/moved", http.StatusFound}}
+	if !reflect.DeepEqual(resp.Redirects, expected) || resp.URL != ts.URL+"/unavailable" {
+		t.Errorf("Expected redirects %v to %s, got %v to %s", expected, ts.URL+"/unavailable", resp.Redirects, resp.URL)
+	}
+
+	session.Redirects = &RedirectPolicy{MaxRedirects: 1}
+	resp, err = session.Do("GET", ts.URL+"/product", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusFound || resp.Header.Get("Location") != "/unavailable" || len(resp.Redirects) != 1 {
+		t.Errorf("Expected to stop at the second redirect, got %d %v", resp.StatusCode, resp.Redirects)
+	}
+
+	session.Redirects = &RedirectPolicy{MaxRedirects: -1}
+	resp, err = session.Do("GET", ts.URL+"/product", nil)
+	if err != nil || resp.StatusCode != http.StatusMovedPermanently || len(resp.Redirects) != 0 {
+		t.Errorf("Expected no redirect to be followed, got %v (%v)", resp, err)
+	}
+
+	session.Redirects = &RedirectPolicy{SameHost: true}
+	resp, err = session.Do("GET", ts.URL+"/external", nil)
+	if err != nil || resp.StatusCode != http.StatusFound || resp.URL != ts.URL+"/external" {
+		t.Errorf("Expected the cross-host redirect not to be followed, got %v (%v)", resp, err)
+	}
+	resp, err = session.Do("GET", ts.URL+"/product", nil)
+	if err != nil || resp.Body != "not available" {
+		t.Errorf("Redirects to the same host should be followed, got %v (%v)", resp, err)
+	}
+}
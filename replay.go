@@ -0,0 +1,192 @@
+package soup
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Recorder is a HTTP transport storing every request together with its response as fixture in a directory,
+// to serve them later by a Replayer. Each fixture is a JSON file named after the method, host and path.
+type Recorder struct {
+	dir       string
+	transport http.RoundTripper
+}
+
+// NewRecorder creates a recorder storing the fixtures in the directory,
+// sending the requests with the transport (http.DefaultTransport if nil)
+func NewRecorder(dir string, transport http.RoundTripper) *Recorder {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &Recorder{dir, transport}
+}
+
+// Replayer is a HTTP transport answering requests with the fixtures stored by a Recorder, without any network access.
+// Requests without a fixture fail with an *UnmatchedRequestError.
+type Replayer struct {
+	dir string
+}
+
+// NewReplayer creates a replayer serving the fixtures of the directory
+func NewReplayer(dir string) *Replayer {
+	return &Replayer{dir}
+}
+
+// UnmatchedRequestError is returned by a Replayer for requests without a fixture
+type UnmatchedRequestError struct {
+	Method  string
+	URL     string
+	Fixture string
+}
+
+func (e *UnmatchedRequestError) Error() string {
+	return "no fixture for " + e.Method + " " + e.URL + " (expected " + e.Fixture + ")"
+}
+
+// fixture is the content of a fixture file
+type fixture struct {
+	Request struct {
+		Method string `json:"method"`
+		URL    string `json:"url"`
+		Body   string `json:"body,omitempty"`
+	} `json:"request"`
+	Response struct {
+		StatusCode   int         `json:"statusCode"`
+		Header       http.Header `json:"header"`
+		Body         string      `json:"body"`
+		BodyEncoding string      `json:"bodyEncoding,omitempty"`
+	} `json:"response"`
+}
+
+var fixtureNameCleaner = regexp.MustCompile(`[^A-Za-z0-9.-]+`)
+
+// returns the file name of the fixture for the request,
+// readable for humans and unique by method, URL and body
+func fixtureName(method string, url string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(method + " " + url + "\n"))
+	hash.Write(body)
+	if scheme := strings.Index(url, "://"); scheme != -1 {
+		url = url[scheme+3:]
+	}
+	readable := strings.Trim(fixtureNameCleaner.ReplaceAllString(url, "_"), "_")
+	if len(readable) > 80 {
+		readable = readable[:80]
+	}
+	return strings.ToUpper(method) + "_" + readable + "_" + hex.EncodeToString(hash.Sum(nil))[:12] + ".json"
+}
+
+// returns the body of the request as it is matched against the fixtures:
+// the random boundary of multipart bodies is replaced by a fixed one
+func matchedBody(header http.Header, body []byte) []byte {
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil || !strings.HasPrefix(mediaType, "multipart/") || params["boundary"] == "" {
+		return body
+	}
+	return bytes.Replace(body, []byte(params["boundary"]), []byte("boundary"), -1)
+}
+
+// reads the body of the request and replaces it by a copy, so it can still be sent
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil {
+		return nil, nil
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// RoundTrip sends the request and stores it together with the response
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	requestBody, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	responseBody, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(responseBody))
+
+	var f fixture
+	f.Request.Method = req.Method
+	f.Request.URL = req.URL.String()
+	f.Request.Body = string(requestBody)
+	f.Response.StatusCode = resp.StatusCode
+	f.Response.Header = resp.Header
+	if utf8.Valid(responseBody) {
+		f.Response.Body = string(responseBody)
+	} else {
+		f.Response.Body = base64.StdEncoding.EncodeToString(responseBody)
+		f.Response.BodyEncoding = "base64"
+	}
+	content, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(r.dir, 0755); err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(filepath.Join(r.dir, fixtureName(req.Method, f.Request.URL, matchedBody(req.Header, requestBody))), content, 0644); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// RoundTrip answers the request with its fixture
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	requestBody, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	name := fixtureName(req.Method, req.URL.String(), matchedBody(req.Header, requestBody))
+	content, err := ioutil.ReadFile(filepath.Join(r.dir, name))
+	if err != nil {
+		return nil, &UnmatchedRequestError{req.Method, req.URL.String(), name}
+	}
+	var f fixture
+	if err := json.Unmarshal(content, &f); err != nil {
+		return nil, err
+	}
+	body := []byte(f.Response.Body)
+	if f.Response.BodyEncoding == "base64" {
+		if body, err = base64.StdEncoding.DecodeString(f.Response.Body); err != nil {
+			return nil, err
+		}
+	}
+	header := f.Response.Header
+	if header == nil {
+		header = make(http.Header)
+	}
+	return &http.Response{
+		Status:        http.StatusText(f.Response.StatusCode),
+		StatusCode:    f.Response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
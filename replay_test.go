@@ -0,0 +1,102 @@
+package soup
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRecordReplay(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Page", r.URL.Path)
+		if r.URL.Path == "/binary" {
+			w.Write([]byte{0xff, 0x00, 0xfe})
+			return
+		}
+		w.Write([]byte("<html><body><p>" + r.Method + " " + r.URL.Path + "</p></body></html>"))
+	}))
+	dir := t.TempDir()
+
+	recording := &http.Client{Transport: NewRecorder(dir, nil)}
+	recorded, err := GetWithClient(ts.URL+"/page", recording)
+	if err != nil {
+		t.Fatal(err)
+	}
+	session := NewSession()
+	session.Client = recording
+	if _, err := session.Post(ts.URL+"/form", map[string][]string{"q": {"soup"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := session.Get(ts.URL + "/binary"); err != nil {
+		t.Fatal(err)
+	}
+	ts.Close()
+
+	replaying := &http.Client{Transport: NewReplayer(dir)}
+	replayed, err := GetWithClient(ts.URL+"/page", replaying)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replayed != recorded {
+		t.Errorf("Replayed body %q differs from recorded body %q", replayed, recorded)
+	}
+	session = NewSession()
+	session.Client = replaying
+	resp, err := session.Post(ts.URL+"/form", map[string][]string{"q": {"soup"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(resp.Body, "POST /form") || resp.Header.Get("X-Page") != "/form" {
+		t.Errorf("Unexpected replayed response %d %v %q", resp.StatusCode, resp.Header, resp.Body)
+	}
+	if body, err := session.Get(ts.URL + "/binary"); err != nil || body != "\xff\x00\xfe" {
+		t.Errorf("Binary body not replayed, got %q (%v)", body, err)
+	}
+
+	_, err = session.Post(ts.URL+"/form", map[string][]string{"q": {"other"}})
+	var unmatched *UnmatchedRequestError
+	if !errors.As(err, &unmatched) {
+		t.Fatalf("Expected an UnmatchedRequestError, got %v", err)
+	}
+	if unmatched.Method != "POST" || unmatched.URL != ts.URL+"/form" {
+		t.Errorf("Unexpected unmatched request %+v", unmatched)
+	}
+}
+
+func TestRecordReplayMultipart(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file, _, err := r.FormFile("upload")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		content, _ := ioutil.ReadAll(file)
+		w.Write([]byte(r.FormValue("q") + " " + string(content)))
+	}))
+	dir := t.TempDir()
+	upload := func(session *Session, content string) (*Response, error) {
+		return session.PostMultipart(ts.URL+"/upload", map[string][]string{"q": {"soup"}},
+			File{Field: "upload", Name: "a.txt", Content: strings.NewReader(content)})
+	}
+
+	session := NewSession()
+	session.Client = &http.Client{Transport: NewRecorder(dir, nil)}
+	if resp, err := upload(session, "file"); err != nil || resp.Body != "soup file" {
+		t.Fatalf("Unexpected recorded response %+v (%v)", resp, err)
+	}
+	ts.Close()
+
+	// the replayed request has another random boundary
+	session = NewSession()
+	session.Client = &http.Client{Transport: NewReplayer(dir)}
+	if resp, err := upload(session, "file"); err != nil || resp.Body != "soup file" {
+		t.Errorf("Multipart request not replayed, got %+v (%v)", resp, err)
+	}
+	var unmatched *UnmatchedRequestError
+	if _, err := upload(session, "other"); !errors.As(err, &unmatched) {
+		t.Errorf("Expected an UnmatchedRequestError for other content, got %v", err)
+	}
+}
@@ -0,0 +1,165 @@
+package soup
+
+import (
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy decides whether and when a failed request of a session is attempted again.
+// Failed requests are those returning one of the StatusCodes or an error accepted by RetryError.
+type RetryPolicy struct {
+	// MaxAttempts is the number of attempts including the first one, values below 2 disable retrying
+	MaxAttempts int
+	// BaseDelay is the delay before the second attempt, doubled for every further attempt
+	BaseDelay time.Duration
+	// MaxDelay caps the delay between attempts, including the one requested by Retry-After
+	MaxDelay time.Duration
+	// Jitter randomly changes each delay by up to the given fraction, e.g. 0.2 for ±20%
+	Jitter float64
+	// StatusCodes are the retried status codes, defaults to 408, 429, 500, 502, 503 and 504
+	StatusCodes []int
+	// Methods are the retried request methods, defaults to the idempotent ones
+	Methods []string
+	// RetryError decides if an error is retried, defaults to timeouts, refused and reset connections
+	RetryError func(error) bool
+	// OnAttempt is called after every attempt
+	OnAttempt func(Attempt)
+}
+
+// Attempt describes a single attempt to perform a request
+type Attempt struct {
+	Number     int
+	Method     string
+	URL        string
+	Proxy      string
+	StatusCode int
+	Err        error
+	// Delay is the time waited before the next attempt, 0 if there is none
+	Delay time.Duration
+}
+
+var defaultRetryStatusCodes = []int{
+	http.StatusRequestTimeout,
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+var defaultRetryMethods = []string{"GET", "HEAD", "OPTIONS", "PUT", "DELETE", "TRACE"}
+
+// NewRetryPolicy creates a policy with the given number of attempts, exponential backoff starting
+// at one second, capped at 30 seconds and with 20% jitter
+func NewRetryPolicy(maxAttempts int) *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts: maxAttempts,
+		BaseDelay:   time.Second,
+		MaxDelay:    30 * time.Second,
+		Jitter:      0.2,
+	}
+}
+
+// checks if the request may be attempted again after the given attempt
+func (p *RetryPolicy) retryable(method string, attempt int, resp *http.Response, err error) bool {
+	if p == nil || attempt >= p.MaxAttempts {
+		return false
+	}
+	methods := p.Methods
+	if methods == nil {
+		methods = defaultRetryMethods
+	}
+	allowed := false
+	for _, m := range methods {
+		allowed = allowed || strings.EqualFold(m, method)
+	}
+	if !allowed {
+		return false
+	}
+	if err != nil {
+		if p.RetryError != nil {
+			return p.RetryError(err)
+		}
+		return temporaryError(err)
+	}
+	statusCodes := p.StatusCodes
+	if statusCodes == nil {
+		statusCodes = defaultRetryStatusCodes
+	}
+	for _, statusCode := range statusCodes {
+		if resp.StatusCode == statusCode {
+			return true
+		}
+	}
+	return false
+}
+
+// returns the delay before the attempt following the given one,
+// using the Retry-After header of 429 and 503 responses if present
+func (p *RetryPolicy) delay(attempt int, resp *http.Response) time.Duration {
+	if resp != nil && (resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable) {
+		if delay, ok := retryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+			if p.MaxDelay > 0 && delay > p.MaxDelay {
+				delay = p.MaxDelay
+			}
+			return delay
+		}
+	}
+	delay := p.BaseDelay
+	for i := 1; i < attempt && (p.MaxDelay <= 0 || delay < p.MaxDelay); i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	if p.Jitter > 0 {
+		delay = time.Duration(float64(delay) * (1 + p.Jitter*(2*rand.Float64()-1)))
+	}
+	if delay < 0 {
+		delay = 0
+	}
+	return delay
+}
+
+// parses the Retry-After header, which is either a number of seconds or a HTTP date
+func retryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	if delay := date.Sub(now); delay > 0 {
+		return delay, true
+	}
+	return 0, true
+}
+
+// checks if the error is a timeout, a refused or reset connection or a connection closed too early
+func temporaryError(err error) bool {
+	var netError net.Error
+	if errors.As(err, &netError) && netError.Timeout() {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNABORTED) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, io.EOF)
+}
@@ -0,0 +1,97 @@
+package soup
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetry(t *testing.T) {
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch atomic.AddInt32(&requests, 1) {
+		case 1:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 2:
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			w.Write([]byte("ok"))
+		}
+	}))
+	defer ts.Close()
+
+	var attempts []Attempt
+	session := NewSession()
+	session.Retry = &RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   time.Millisecond,
+		OnAttempt:   func(a Attempt) { attempts = append(attempts, a) },
+	}
+	actual, err := session.Get(ts.URL)
+	if err != nil || actual != "ok" {
+		t.Fatalf("Expected the third attempt to succeed: %s (%v)", actual, err)
+	}
+	if len(attempts) != 3 || attempts[0].StatusCode != 503 || attempts[1].StatusCode != 502 || attempts[2].StatusCode != 200 {
+		t.Errorf("Wrong attempts: %+v", attempts)
+	}
+	if attempts[0].Delay != 0 || attempts[1].Delay != 2*time.Millisecond || attempts[2].Delay != 0 {
+		t.Errorf("Wrong delays: %+v", attempts)
+	}
+
+	// the last response is returned once all attempts are used up
+	atomic.StoreInt32(&requests, 0)
+	session.Retry.MaxAttempts = 2
+	resp, err := session.Do("GET", ts.URL, nil)
+	if err != nil || resp.StatusCode != http.StatusBadGateway {
+		t.Errorf("Expected the 502 response of the last attempt: %+v (%v)", resp, err)
+	}
+
+	// POST isn't idempotent and not retried by default
+	atomic.StoreInt32(&requests, 0)
+	resp, err = session.Post(ts.URL, url.Values{})
+	if err != nil || resp.StatusCode != http.StatusServiceUnavailable || atomic.LoadInt32(&requests) != 1 {
+		t.Errorf("POST should not be retried: %+v (%v)", resp, err)
+	}
+}
+
+func TestRetryConnectionError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	address := ts.URL
+	ts.Close()
+
+	attempts := 0
+	session := NewSession()
+	session.Retry = &RetryPolicy{MaxAttempts: 2, OnAttempt: func(a Attempt) {
+		attempts++
+		if a.Err == nil {
+			t.Errorf("Attempt should report the error")
+		}
+	}}
+	if _, err := session.Get(address); err == nil {
+		t.Errorf("Request to a closed server should fail")
+	}
+	if attempts != 2 {
+		t.Errorf("Refused connection should be retried, got %d attempts", attempts)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	if delay, ok := retryAfter("120", now); !ok || delay != 2*time.Minute {
+		t.Errorf("Wrong delay: %v", delay)
+	}
+	if delay, ok := retryAfter("Wed, 01 Jan 2020 12:00:30 GMT", now); !ok || delay != 30*time.Second {
+		t.Errorf("Wrong delay: %v", delay)
+	}
+	if _, ok := retryAfter("soon", now); ok {
+		t.Errorf("Invalid header should be ignored")
+	}
+	policy := &RetryPolicy{MaxAttempts: 5, BaseDelay: time.Second, MaxDelay: 5 * time.Second}
+	if delay := policy.delay(4, nil); delay != 5*time.Second {
+		t.Errorf("Delay should be capped: %v", delay)
+	}
+}
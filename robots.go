@@ -0,0 +1,302 @@
+package soup
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net/http"
+	neturl "net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RobotsPolicy makes a session respect the robots.txt of every host it sends requests to
+type RobotsPolicy struct {
+	// UserAgent is matched against the User-agent lines, defaults to the User-Agent header of the session
+	UserAgent string
+	// IgnoreCrawlDelay stops the Crawl-delay from being used to throttle the requests
+	IgnoreCrawlDelay bool
+}
+
+// DisallowedError is returned for requests to URLs disallowed by the robots.txt of their host
+type DisallowedError struct {
+	URL       string
+	UserAgent string
+}
+
+func (e *DisallowedError) Error() string {
+	return "access to " + e.URL + " is disallowed by robots.txt for user agent `" + e.UserAgent + "`"
+}
+
+// RobotsUnavailableError is returned for requests to hosts whose robots.txt couldn't be downloaded because of
+// a transport error or a server error status, the request may be allowed once the host is reachable again
+type RobotsUnavailableError struct {
+	URL string
+	// StatusCode is the status of the robots.txt response, 0 if the request failed, Err the error it failed with
+	StatusCode int
+	Err        error
+}
+
+func (e *RobotsUnavailableError) Error() string {
+	if e.Err != nil {
+		return "robots.txt " + e.URL + " is unavailable: " + e.Err.Error()
+	}
+	return "robots.txt " + e.URL + " is unavailable: status " + strconv.Itoa(e.StatusCode)
+}
+
+func (e *RobotsUnavailableError) Unwrap() error {
+	return e.Err
+}
+
+// Robots contains the parsed rules of a robots.txt file
+type Robots struct {
+	groups   []robotsGroup
+	Sitemaps []string
+}
+
+// robotsGroup contains the rules of one or more user agents
+type robotsGroup struct {
+	agents     []string
+	rules      []robotsRule
+	crawlDelay time.Duration
+}
+
+// robotsRule is a single Allow or Disallow line
+type robotsRule struct {
+	allow   bool
+	length  int
+	pattern *regexp.Regexp
+}
+
+// ParseRobots parses the content of a robots.txt file
+func ParseRobots(content string) *Robots {
+	robots := &Robots{}
+	var group *robotsGroup
+	groupHasRules := false
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if comment := strings.IndexByte(line, '#'); comment != -1 {
+			line = line[:comment]
+		}
+		colon := strings.IndexByte(line, ':')
+		if colon == -1 {
+			continue
+		}
+		key := strings.ToLower(strings.TrimSpace(line[:colon]))
+		value := strings.TrimSpace(line[colon+1:])
+		switch key {
+		case "user-agent":
+			if group == nil || groupHasRules {
+				robots.groups = append(robots.groups, robotsGroup{})
+				group = &robots.groups[len(robots.groups)-1]
+				groupHasRules = false
+			}
+			group.agents = append(group.agents, strings.ToLower(value))
+		case "allow", "disallow":
+			if group == nil {
+				continue
+			}
+			groupHasRules = true
+			if value == "" {
+				// an empty Disallow allows everything, an empty Allow has no effect
+				continue
+			}
+			group.rules = append(group.rules, robotsRule{key == "allow", len(value), compileRobotsPattern(value)})
+		case "crawl-delay":
+			if group == nil {
+				continue
+			}
+			groupHasRules = true
+			if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+				group.crawlDelay = time.Duration(seconds * float64(time.Second))
+			}
+		case "sitemap":
+			if value != "" {
+				robots.Sitemaps = append(robots.Sitemaps, value)
+			}
+		}
+	}
+	return robots
+}
+
+// compiles a path pattern with `*` wildcards and an optional `$` anchor
+func compileRobotsPattern(pattern string) *regexp.Regexp {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+	parts := strings.Split(pattern, "*")
+	for position := range parts {
+		parts[position] = regexp.QuoteMeta(parts[position])
+	}
+	expression := "^" + strings.Join(parts, ".*")
+	if anchored {
+		expression += "$"
+	}
+	return regexp.MustCompile(expression)
+}
+
+// returns the groups applying to the user agent: those naming it most specifically, otherwise those for `*`
+func (r *Robots) groupsFor(userAgent string) []*robotsGroup {
+	token := strings.ToLower(userAgent)
+	if slash := strings.IndexByte(token, '/'); slash != -1 {
+		token = token[:slash]
+	}
+	token = strings.TrimSpace(token)
+	var matching, wildcard []*robotsGroup
+	longest := 0
+	for position := range r.groups {
+		group := &r.groups[position]
+		for _, agent := range group.agents {
+			if agent == "*" {
+				wildcard = append(wildcard, group)
+			} else if agent != "" && strings.Contains(token, agent) {
+				if len(agent) > longest {
+					longest = len(agent)
+					matching = nil
+				}
+				if len(agent) == longest {
+					matching = append(matching, group)
+				}
+			}
+		}
+	}
+	if len(matching) > 0 {
+		return matching
+	}
+	return wildcard
+}
+
+// Allowed checks if the user agent may access the path (including the query) of a URL.
+// The longest matching rule decides, Allow wins if an Allow and a Disallow rule are equally long.
+func (r *Robots) Allowed(userAgent string, path string) bool {
+	if path == "" {
+		path = "/"
+	}
+	if path == "/robots.txt" {
+		return true
+	}
+	allowed := true
+	longest := -1
+	for _, group := range r.groupsFor(userAgent) {
+		for _, rule := range group.rules {
+			if !rule.pattern.MatchString(path) {
+				continue
+			}
+			if rule.length > longest || (rule.length == longest && rule.allow) {
+				longest = rule.length
+				allowed = rule.allow
+			}
+		}
+	}
+	return allowed
+}
+
+// CrawlDelay returns the delay between two requests requested for the user agent, 0 if there is none
+func (r *Robots) CrawlDelay(userAgent string) time.Duration {
+	var delay time.Duration
+	for _, group := range r.groupsFor(userAgent) {
+		if group.crawlDelay > delay {
+			delay = group.crawlDelay
+		}
+	}
+	return delay
+}
+
+// robotsEntry caches the robots.txt of a single host
+type robotsEntry struct {
+	mu     sync.Mutex
+	robots *Robots
+}
+
+// Robots returns the parsed robots.txt of the host of the url, downloading it if it isn't cached yet.
+// A missing robots.txt allows everything, while a server error or an unreachable host returns a RobotsUnavailableError
+// until the next request tries to download it again.
+func (s *Session) Robots(url string) (*Robots, error) {
+	return s.robots(context.Background(), url)
+}
+
+// returns the robots.txt of the host of the url, downloading it with the context if it isn't cached yet
+func (s *Session) robots(ctx context.Context, url string) (*Robots, error) {
+	target, err := neturl.Parse(url)
+	if err != nil || target.Host == "" {
+		if debug {
+			panic("Unable to parse the url " + url)
+		}
+		return nil, errors.New("unable to parse the url " + url)
+	}
+	key := target.Scheme + "://" + target.Host
+	state := s.shared()
+	state.mu.Lock()
+	entry, ok := state.robots[key]
+	if !ok {
+		entry = &robotsEntry{}
+		state.robots[key] = entry
+	}
+	state.mu.Unlock()
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+	if entry.robots != nil {
+		return entry.robots, nil
+	}
+	resp, err := s.send(&request{ctx: ctx, method: "GET", url: key + "/robots.txt"})
+	switch {
+	case err != nil:
+		return nil, &RobotsUnavailableError{key + "/robots.txt", 0, err}
+	case resp.StatusCode >= 500:
+		return nil, &RobotsUnavailableError{key + "/robots.txt", resp.StatusCode, nil}
+	case resp.StatusCode >= 400:
+		entry.robots = &Robots{}
+	default:
+		entry.robots = ParseRobots(resp.Body)
+	}
+	return entry.robots, nil
+}
+
+// returns the user agent matched against robots.txt
+func (s *Session) robotsUserAgent() string {
+	if s.RobotsPolicy != nil && s.RobotsPolicy.UserAgent != "" {
+		return s.RobotsPolicy.UserAgent
+	}
+	for name, value := range s.Headers {
+		if http.CanonicalHeaderKey(name) == "User-Agent" {
+			return value
+		}
+	}
+	if len(s.UserAgents) > 0 {
+		return s.UserAgents[0]
+	}
+	if userAgent, ok := s.Profile["User-Agent"]; ok {
+		return userAgent
+	}
+	return "Go-http-client"
+}
+
+// checks if robots.txt allows the request, feeding the Crawl-delay into the throttling of the host
+func (s *Session) checkRobots(ctx context.Context, target *neturl.URL) error {
+	if s.RobotsPolicy == nil || target.Host == "" {
+		return nil
+	}
+	robots, err := s.robots(ctx, target.String())
+	if err != nil {
+		return err
+	}
+	userAgent := s.robotsUserAgent()
+	if !s.RobotsPolicy.IgnoreCrawlDelay {
+		l := s.limiter(canonicalHost(target.Host))
+		l.mu.Lock()
+		l.crawlDelay = robots.CrawlDelay(userAgent)
+		l.mu.Unlock()
+	}
+	path := target.EscapedPath()
+	if target.RawQuery != "" {
+		path += "?" + target.RawQuery
+	}
+	if !robots.Allowed(userAgent, path) {
+		return &DisallowedError{target.String(), userAgent}
+	}
+	return nil
+}
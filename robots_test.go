@@ -0,0 +1,140 @@
+package soup
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const robotsTXT = `
+# comment
+User-agent: *
+Disallow: /private/
+Allow: /private/public$
+Disallow: /*.pdf$
+Crawl-delay: 0.01
+
+User-agent: soupbot
+User-agent: otherbot
+Disallow: /
+Allow: /search?
+Crawl-delay: 2
+
+Sitemap: https://example.com/sitemap.xml
+`
+
+func TestRobotsAllowed(t *testing.T) {
+	robots := ParseRobots(robotsTXT)
+	tests := []struct {
+		userAgent string
+		path      string
+		allowed   bool
+	}{
+		{"Mozilla/5.0", "/", true},
+		{"Mozilla/5.0", "/private/page", false},
+		{"Mozilla/5.0", "/private/public", true},
+		{"Mozilla/5.0", "/private/public/more", false},
+		{"Mozilla/5.0", "/files/report.pdf", false},
+		{"Mozilla/5.0", "/files/report.pdf?download", true},
+		{"SoupBot/1.0", "/page", false},
+		{"SoupBot/1.0", "/search?q=soup", true},
+		{"SoupBot/1.0", "/robots.txt", true},
+	}
+	for _, test := range tests {
+		if robots.Allowed(test.userAgent, test.path) != test.allowed {
+			t.Errorf("Allowed(%s, %s) should be %v", test.userAgent, test.path, test.allowed)
+		}
+	}
+	if robots.CrawlDelay("soupbot") != 2*time.Second || robots.CrawlDelay("curl") != 10*time.Millisecond {
+		t.Errorf("Wrong crawl delays")
+	}
+	if len(robots.Sitemaps) != 1 || robots.Sitemaps[0] != "https://example.com/sitemap.xml" {
+		t.Errorf("Wrong sitemaps: %v", robots.Sitemaps)
+	}
+}
+
+func TestSessionRobots(t *testing.T) {
+	var robotsRequests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			atomic.AddInt32(&robotsRequests, 1)
+			w.Write([]byte(robotsTXT))
+			return
+		}
+		w.Write([]byte("page"))
+	}))
+	defer ts.Close()
+
+	session := NewSession()
+	session.RobotsPolicy = &RobotsPolicy{UserAgent: "Mozilla/5.0"}
+	if actual, err := session.Get(ts.URL + "/page"); err != nil || actual != "page" {
+		t.Errorf("Allowed page should be fetched: %s (%v)", actual, err)
+	}
+	_, err := session.Get(ts.URL + "/private/page")
+	var disallowed *DisallowedError
+	if !errors.As(err, &disallowed) || disallowed.URL != ts.URL+"/private/page" {
+		t.Errorf("Disallowed page should return a DisallowedError, got %v", err)
+	}
+	if atomic.LoadInt32(&robotsRequests) != 1 {
+		t.Errorf("robots.txt should be downloaded once, got %d requests", robotsRequests)
+	}
+	if session.limiter("127.0.0.1").crawlDelay != 10*time.Millisecond {
+		t.Errorf("Crawl-delay should be used for throttling")
+	}
+}
+
+func TestSessionRobotsMissing(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("page"))
+	}))
+	defer ts.Close()
+
+	session := NewSession()
+	session.RobotsPolicy = &RobotsPolicy{}
+	if actual, err := session.Get(ts.URL + "/private/page"); err != nil || actual != "page" {
+		t.Errorf("Missing robots.txt should allow everything: %s (%v)", actual, err)
+	}
+}
+
+func TestSessionRobotsUnavailable(t *testing.T) {
+	var down int32 = 1
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			if atomic.LoadInt32(&down) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Write([]byte(robotsTXT))
+			return
+		}
+		w.Write([]byte("page"))
+	}))
+	defer ts.Close()
+
+	session := NewSession()
+	session.RobotsPolicy = &RobotsPolicy{}
+	_, err := session.Get(ts.URL + "/page")
+	var unavailable *RobotsUnavailableError
+	var disallowed *DisallowedError
+	if !errors.As(err, &unavailable) || errors.As(err, &disallowed) || unavailable.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Server error for robots.txt should return a RobotsUnavailableError, got %v", err)
+	}
+	atomic.StoreInt32(&down, 0)
+	if actual, err := session.Get(ts.URL + "/page"); err != nil || actual != "page" {
+		t.Errorf("robots.txt should be downloaded again once the host is up: %s (%v)", actual, err)
+	}
+
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+	_, err = session.Get(closed.URL + "/page")
+	if !errors.As(err, &unavailable) || errors.As(err, &disallowed) || unavailable.Err == nil {
+		t.Errorf("Unreachable host should return a RobotsUnavailableError, got %v", err)
+	}
+}
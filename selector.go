@@ -0,0 +1,209 @@
+package soup
+
+import (
+	"errors"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// selector is a compiled subset of CSS selectors:
+// type, universal, #id, .class and [attribute] selectors (with =, ~=, |=, ^=, $= and *=)
+// combined by the descendant and the child combinator
+type selector []selectorStep
+
+// selectorStep is a compound selector together with the combinator relating it to the previous step
+type selectorStep struct {
+	child      bool
+	tag        string
+	id         string
+	classes    []string
+	attributes []attributeSelector
+}
+
+// attributeSelector matches the value of a single attribute
+type attributeSelector struct {
+	name     string
+	operator string
+	value    string
+}
+
+// compiles the selector string, returning an error if it is malformed
+func compileSelector(s string) (selector, error) {
+	var sel selector
+	child := false
+	for _, field := range strings.Fields(strings.Replace(s, ">", " > ", -1)) {
+		if field == ">" {
+			if child || len(sel) == 0 {
+				return nil, errors.New("selector `" + s + "` has a misplaced `>`")
+			}
+			child = true
+			continue
+		}
+		step, err := compileSelectorStep(field)
+		if err != nil {
+			return nil, errors.New("selector `" + s + "` is invalid: " + err.Error())
+		}
+		step.child = child
+		child = false
+		sel = append(sel, step)
+	}
+	if len(sel) == 0 || child {
+		return nil, errors.New("selector `" + s + "` is incomplete")
+	}
+	return sel, nil
+}
+
+// compiles a single compound selector like `div#main.article[data-id]`
+func compileSelectorStep(s string) (selectorStep, error) {
+	var step selectorStep
+	position := 0
+	readName := func() string {
+		start := position
+		for position < len(s) && !strings.ContainsRune("#.[", rune(s[position])) {
+			position++
+		}
+		return s[start:position]
+	}
+	step.tag = readName()
+	if step.tag == "*" {
+		step.tag = ""
+	}
+	for position < len(s) {
+		switch s[position] {
+		case '#':
+			position++
+			step.id = readName()
+			if step.id == "" {
+				return step, errors.New("empty id")
+			}
+		case '.':
+			position++
+			class := readName()
+			if class == "" {
+				return step, errors.New("empty class")
+			}
+			step.classes = append(step.classes, class)
+		case '[':
+			end := strings.IndexByte(s[position:], ']')
+			if end == -1 {
+				return step, errors.New("unclosed attribute selector")
+			}
+			attribute, err := compileAttributeSelector(s[position+1 : position+end])
+			if err != nil {
+				return step, err
+			}
+			step.attributes = append(step.attributes, attribute)
+			position += end + 1
+		}
+	}
+	return step, nil
+}
+
+// compiles the inside of an attribute selector like `href^="https"`
+func compileAttributeSelector(s string) (attributeSelector, error) {
+	equals := strings.IndexByte(s, '=')
+	if equals == -1 {
+		if strings.TrimSpace(s) == "" {
+			return attributeSelector{}, errors.New("empty attribute selector")
+		}
+		return attributeSelector{name: strings.TrimSpace(s)}, nil
+	}
+	attribute := attributeSelector{operator: "="}
+	name := s[:equals]
+	if equals > 0 && strings.ContainsRune("~|^$*", rune(s[equals-1])) {
+		attribute.operator = s[equals-1 : equals+1]
+		name = s[:equals-1]
+	}
+	attribute.name = strings.TrimSpace(name)
+	attribute.value = strings.Trim(strings.TrimSpace(s[equals+1:]), `"'`)
+	if attribute.name == "" {
+		return attribute, errors.New("attribute selector without name")
+	}
+	return attribute, nil
+}
+
+// checks if the node matches the compound selector
+func (step selectorStep) matches(n *html.Node) bool {
+	if n.Type != html.ElementNode || (step.tag != "" && step.tag != n.Data) {
+		return false
+	}
+	if step.id != "" && attributeOrEmpty(n, "id") != step.id {
+		return false
+	}
+	if len(step.classes) > 0 && !compareAttributeValues(false, attributeOrEmpty(n, "class"), strings.Join(step.classes, " ")) {
+		return false
+	}
+	for _, attribute := range step.attributes {
+		value, ok := attributeValue(n, attribute.name)
+		if !ok {
+			return false
+		}
+		switch attribute.operator {
+		case "=":
+			ok = value == attribute.value
+		case "~=":
+			ok = compareAttributeValues(false, value, attribute.value) && attribute.value != ""
+		case "|=":
+			ok = value == attribute.value || strings.HasPrefix(value, attribute.value+"-")
+		case "^=":
+			ok = attribute.value != "" && strings.HasPrefix(value, attribute.value)
+		case "$=":
+			ok = attribute.value != "" && strings.HasSuffix(value, attribute.value)
+		case "*=":
+			ok = attribute.value != "" && strings.Contains(value, attribute.value)
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// checks if the node matches the whole selector, walking up the ancestors for the combinators
+func (sel selector) matches(n *html.Node) bool {
+	return sel.matchesFrom(n, len(sel)-1)
+}
+
+func (sel selector) matchesFrom(n *html.Node, last int) bool {
+	if !sel[last].matches(n) {
+		return false
+	}
+	if last == 0 {
+		return true
+	}
+	for ancestor := n.Parent; ancestor != nil; ancestor = ancestor.Parent {
+		if sel.matchesFrom(ancestor, last-1) {
+			return true
+		}
+		if sel[last].child {
+			break
+		}
+	}
+	return false
+}
+
+// finds all elements beneath the given Root struct matching the selector
+func (r Root) selectAll(sel selector) []Root {
+	var results []Root
+	for _, child := range r.Children() {
+		if sel.matches(child.Pointer) {
+			results = append(results, child)
+		}
+		results = append(results, child.selectAll(sel)...)
+	}
+	return results
+}
+
+// finds the first element beneath the given Root struct matching the selector
+func (r Root) selectOnce(sel selector) (Root, bool) {
+	for _, child := range r.Children() {
+		if sel.matches(child.Pointer) {
+			return child, true
+		}
+		if result, ok := child.selectOnce(sel); ok {
+			return result, true
+		}
+	}
+	return Root{}, false
+}
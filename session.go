@@ -0,0 +1,403 @@
+package soup
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	neturl "net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Session keeps the state shared by consecutive requests.
+// Cookies set by the responses are stored in the Jar and sent back only to the matching domains and paths,
+// while Headers and Cookies are sent with every request.
+// Failed requests are attempted again according to the Retry policy, if one is set,
+// and the requests to each host are throttled according to the RateLimit and the RobotsPolicy.
+// The package level functions like Get() use a session with the global Headers and Cookies and without a jar.
+type Session struct {
+	Client  *http.Client
+	Headers map[string]string
+	Cookies map[string]string
+	Jar     *Jar
+	Retry   *RetryPolicy
+	// RateLimit throttles the requests to every host, unless HostRateLimits has an entry for the host or one of its parent domains
+	RateLimit      *RateLimit
+	HostRateLimits map[string]*RateLimit
+	// RobotsPolicy makes the session refuse URLs disallowed by robots.txt, nil disables the check
+	RobotsPolicy *RobotsPolicy
+	// Cache stores the responses of GET requests, honouring Cache-Control and Expires
+	// and revalidating stale responses with If-None-Match and If-Modified-Since
+	Cache Cache
+	// ForceCache answers every cached GET request from the cache regardless of its freshness
+	// and never hits the network for them, requests which aren't cached fail with ErrNotCached
+	ForceCache bool
+	// MaxBodySize limits the size of the decoded response bodies in bytes, 0 means unlimited
+	MaxBodySize int64
+	// Profile is a set of browser headers sent with every request, overridden by UserAgents and Headers
+	Profile HeaderProfile
+	// UserAgents is a list of user agents used one after another, or randomly if RandomUserAgent is set
+	UserAgents      []string
+	RandomUserAgent bool
+	// Redirects limits the redirects followed, nil uses the redirect policy of the client
+	Redirects *RedirectPolicy
+	// Credentials authenticates the requests to the hosts, given with or without port, including redirected requests
+	Credentials map[string]Credentials
+	// Proxies sends the requests through a pool of proxies, nil uses the proxy settings of the client
+	Proxies *ProxyPool
+
+	state *sessionState
+}
+
+// sessionState is the internal state of a session, shared by all its requests
+type sessionState struct {
+	mu       sync.Mutex
+	limiters map[string]*hostLimiter
+	robots   map[string]*robotsEntry
+	// userAgents counts the requests for the rotation of the user agents
+	userAgents int
+}
+
+// guards the creation of the session states
+var sessionStateMu sync.Mutex
+
+// returns the internal state of the session, creating it if needed
+func (s *Session) shared() *sessionState {
+	sessionStateMu.Lock()
+	defer sessionStateMu.Unlock()
+	if s.state == nil {
+		s.state = &sessionState{
+			limiters: make(map[string]*hostLimiter),
+			robots:   make(map[string]*robotsEntry),
+		}
+	}
+	return s.state
+}
+
+// NewSession creates a session with its own cookie jar
+func NewSession() *Session {
+	return &Session{
+		Client:  &http.Client{},
+		Headers: make(map[string]string),
+		Cookies: make(map[string]string),
+		Jar:     NewJar(),
+	}
+}
+
+// Get returns the HTML returned by the url
+func (s *Session) Get(url string) (string, error) {
+	resp, err := s.do(context.Background(), "GET", url, nil, "")
+	if err != nil {
+		return "", err
+	}
+	return resp.Body, nil
+}
+
+// Post sends the values form-encoded to the url
+func (s *Session) Post(url string, values neturl.Values) (*Response, error) {
+	return s.do(context.Background(), "POST", url, strings.NewReader(values.Encode()), "application/x-www-form-urlencoded")
+}
+
+// PostJSON sends the value encoded as JSON to the url
+func (s *Session) PostJSON(url string, v interface{}) (*Response, error) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		if debug {
+			panic("Unable to encode the value as JSON")
+		}
+		return nil, errors.New("unable to encode the value as JSON")
+	}
+	return s.do(context.Background(), "POST", url, bytes.NewReader(body), "application/json")
+}
+
+// PostMultipart sends the values and files as multipart/form-data to the url
+func (s *Session) PostMultipart(url string, values neturl.Values, files ...File) (*Response, error) {
+	body, contentType, err := encodeMultipart(values, files)
+	if err != nil {
+		if debug {
+			panic("Unable to encode the multipart body")
+		}
+		return nil, errors.New("unable to encode the multipart body")
+	}
+	return s.do(context.Background(), "POST", url, body, contentType)
+}
+
+// Do sends a request with any method and an optional body to the url.
+// The content type of the body can be passed as well, otherwise it is taken from the Headers.
+func (s *Session) Do(method string, url string, body io.Reader, contentType ...string) (*Response, error) {
+	return s.DoContext(context.Background(), method, url, body, contentType...)
+}
+
+// DoContext is like Do, but the request, its retries and the throttling of its host are cancelled once the context is done,
+// returning an error wrapping the error of the context
+func (s *Session) DoContext(ctx context.Context, method string, url string, body io.Reader, contentType ...string) (*Response, error) {
+	if len(contentType) == 1 {
+		return s.do(ctx, method, url, body, contentType[0])
+	}
+	return s.do(ctx, method, url, body, "")
+}
+
+// Submit submits the form, see Form.SubmitWithClient
+func (s *Session) Submit(f Form) (*Response, error) {
+	method, target, body, contentType, err := f.request()
+	if err != nil {
+		if debug {
+			panic(err.Error())
+		}
+		return nil, err
+	}
+	return s.do(context.Background(), method, target, body, contentType)
+}
+
+// returns the HTTP client used for the requests, sharing the cookie jar of the session
+func (s *Session) httpClient() *http.Client {
+	client := &http.Client{}
+	if s.Client != nil {
+		*client = *s.Client
+	}
+	if s.Jar != nil {
+		client.Jar = s.Jar
+	}
+	if s.Redirects != nil {
+		client.CheckRedirect = s.Redirects.check
+	}
+	return client
+}
+
+// request is a request sent by a session
+type request struct {
+	// ctx cancels the request, nil means it can't be cancelled
+	ctx         context.Context
+	method      string
+	url         string
+	payload     []byte
+	contentType string
+	// header contains additional headers of this request only
+	header http.Header
+}
+
+// performs a request with the given method and body,
+// sending the headers and cookies, and returns the response.
+// Requests disallowed by robots.txt are refused with a *DisallowedError if the session respects it.
+// GET requests are answered from the cache while fresh and revalidated once they are stale.
+func (s *Session) do(ctx context.Context, method string, url string, body io.Reader, contentType string) (*Response, error) {
+	target, err := neturl.Parse(url)
+	if err != nil {
+		if debug {
+			panic("Couldn't perform " + method + " request to " + url)
+		}
+		return nil, errors.New("couldn't perform " + method + " request to " + url)
+	}
+	req := &request{ctx: ctx, method: method, url: url, contentType: contentType}
+	// the body is kept to send it again with every attempt
+	if body != nil {
+		req.payload, err = ioutil.ReadAll(body)
+		if err != nil {
+			if debug {
+				panic("Unable to read the request body")
+			}
+			return nil, errors.New("unable to read the request body")
+		}
+	}
+
+	var cached *CachedResponse
+	cacheKey := method + " " + url
+	useCache := s.Cache != nil && method == "GET"
+	if useCache {
+		entry, ok := s.Cache.Get(cacheKey)
+		switch {
+		case ok && (s.ForceCache || entry.fresh(time.Now())):
+			return entry.response(), nil
+		case s.ForceCache:
+			if debug {
+				panic(ErrNotCached.Error())
+			}
+			return nil, ErrNotCached
+		case ok:
+			cached = entry
+			req.header = entry.conditionalHeader()
+		}
+	}
+
+	if err := s.checkRobots(ctx, target); err != nil {
+		if debug {
+			panic(err.Error())
+		}
+		return nil, err
+	}
+	resp, err := s.send(req)
+	if err != nil || !useCache {
+		return resp, err
+	}
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		revalidated := *cached
+		revalidated.Header = cached.Header.Clone()
+		for name, values := range resp.Header {
+			revalidated.Header[name] = values
+		}
+		revalidated.Stored = time.Now()
+		s.Cache.Set(cacheKey, &revalidated)
+		return revalidated.response(), nil
+	}
+	if cacheable(resp) {
+		s.Cache.Set(cacheKey, &CachedResponse{resp.StatusCode, resp.Header, resp.URL, resp.Body, time.Now()})
+	}
+	return resp, nil
+}
+
+// sends the request, repeating failed attempts as long as the retry policy allows it
+func (s *Session) send(req *request) (*Response, error) {
+	method, url := req.method, req.url
+	target, err := neturl.Parse(url)
+	if err != nil {
+		if debug {
+			panic("Couldn't perform " + method + " request to " + url)
+		}
+		return nil, errors.New("couldn't perform " + method + " request to " + url)
+	}
+	ctx := req.context()
+	for attempt := 1; ; attempt++ {
+		release, err := s.throttle(ctx, target.Host)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't perform %s request to %s: %w", method, url, err)
+		}
+		resp, proxy, err := s.attempt(req)
+		if err == errInvalidRequest {
+			release()
+			if debug {
+				panic("Couldn't perform " + method + " request to " + url)
+			}
+			return nil, errors.New("couldn't perform " + method + " request to " + url)
+		}
+		// a cancelled request isn't attempted again
+		retry := ctx.Err() == nil && s.Retry.retryable(method, attempt, resp, err)
+		var delay time.Duration
+		if retry {
+			delay = s.Retry.delay(attempt, resp)
+		}
+		if s.Retry != nil && s.Retry.OnAttempt != nil {
+			report := Attempt{Number: attempt, Method: method, URL: url, Proxy: proxy, Err: err, Delay: delay}
+			if resp != nil {
+				report.StatusCode = resp.StatusCode
+			}
+			s.Retry.OnAttempt(report)
+		}
+		if retry {
+			if resp != nil {
+				resp.Body.Close()
+			}
+			release()
+			timer := time.NewTimer(delay)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return nil, fmt.Errorf("couldn't perform %s request to %s: %w", method, url, ctx.Err())
+			}
+			continue
+		}
+		if err != nil {
+			release()
+			if ctx.Err() != nil {
+				return nil, fmt.Errorf("couldn't perform %s request to %s: %w", method, url, ctx.Err())
+			}
+			if debug {
+				panic("Couldn't perform " + method + " request to " + url + ": " + err.Error())
+			}
+			return nil, fmt.Errorf("couldn't perform %s request to %s: %w", method, url, err)
+		}
+		bytes, err := s.readBody(resp, url)
+		resp.Body.Close()
+		release()
+		if tooLarge, ok := err.(*BodyTooLargeError); ok {
+			if debug {
+				panic(tooLarge.Error())
+			}
+			return nil, tooLarge
+		}
+		if err != nil {
+			if debug {
+				panic("Unable to read the response body")
+			}
+			return nil, errors.New("unable to read the response body")
+		}
+		// custom transports may not set the request of the response
+		finalURL := url
+		if resp.Request != nil {
+			finalURL = resp.Request.URL.String()
+		}
+		return &Response{
+			StatusCode: resp.StatusCode,
+			Header:     resp.Header,
+			URL:        finalURL,
+			Body:       string(bytes),
+			Proxy:      proxy,
+			Redirects:  redirectChain(resp),
+		}, nil
+	}
+}
+
+// returns the context of the request, the background context if it has none
+func (r *request) context() context.Context {
+	if r.ctx == nil {
+		return context.Background()
+	}
+	return r.ctx
+}
+
+// returned by attempt if the request can't be created at all
+var errInvalidRequest = errors.New("invalid request")
+
+// performs a single attempt of the request
+func (s *Session) attempt(r *request) (*http.Response, string, error) {
+	var body io.Reader
+	if r.payload != nil {
+		body = bytes.NewReader(r.payload)
+	}
+	req, err := http.NewRequestWithContext(r.context(), r.method, r.url, body)
+	if err != nil {
+		return nil, "", errInvalidRequest
+	}
+	// Set headers
+	s.setHeaders(req.Header)
+	for hName, hValues := range r.header {
+		req.Header[hName] = hValues
+	}
+	if r.contentType != "" {
+		req.Header.Set("Content-Type", r.contentType)
+	}
+	if req.Header.Get("Accept-Encoding") == "" {
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+	// Set cookies
+	for cName, cValue := range s.Cookies {
+		req.AddCookie(&http.Cookie{
+			Name:  cName,
+			Value: cValue,
+		})
+	}
+	// Perform request
+	client := s.httpClient()
+	proxy, position := "", -1
+	if s.Proxies != nil {
+		if position, err = s.Proxies.pick(); err != nil {
+			return nil, "", err
+		}
+		client.Transport = s.Proxies.transport(position, client.Transport)
+		proxy = s.Proxies.proxies[position].Redacted()
+	}
+	if len(s.Credentials) > 0 {
+		client.Transport = &credentialsTransport{client.Transport, s.Credentials}
+	}
+	resp, err := client.Do(req)
+	if position != -1 {
+		s.Proxies.report(position, err != nil || resp.StatusCode == http.StatusProxyAuthRequired)
+	}
+	return resp, proxy, err
+}
@@ -0,0 +1,75 @@
+package soup
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSessionCookies(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "secret", Path: "/"})
+			http.Redirect(w, r, "/account", http.StatusFound)
+		default:
+			cookie, err := r.Cookie("session")
+			if err != nil {
+				w.Write([]byte("anonymous"))
+				return
+			}
+			w.Write([]byte(cookie.Value + " " + r.Header.Get("X-Session")))
+		}
+	}))
+	defer ts.Close()
+
+	session := NewSession()
+	session.Headers["X-Session"] = "header"
+	actual, err := session.Get(ts.URL + "/login")
+	if err != nil || actual != "secret header" {
+		t.Errorf("Cookie set during the redirect should be sent: %s (%v)", actual, err)
+	}
+	actual, _ = session.Get(ts.URL + "/account")
+	if actual != "secret header" {
+		t.Errorf("Cookie should be kept by the session: %s", actual)
+	}
+	if len(session.Jar.All()) != 1 {
+		t.Errorf("Cookie should be stored in the jar: %+v", session.Jar.All())
+	}
+
+	actual, _ = Get(ts.URL + "/account")
+	if actual != "anonymous" {
+		t.Errorf("Package level requests should not share the session cookies: %s", actual)
+	}
+}
+
+func TestSessionDoContext(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			// answers only once the client gave up
+			<-r.Context().Done()
+			return
+		}
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	session := NewSession()
+	session.Retry = &RetryPolicy{MaxAttempts: 3}
+	for _, path := range []string{"/slow", "/unavailable"} {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		start := time.Now()
+		_, err := session.DoContext(ctx, "GET", ts.URL+path, nil)
+		cancel()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected the error of the context for %s, got %v", path, err)
+		}
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("Request to %s should be aborted with the context, took %v", path, elapsed)
+		}
+	}
+}
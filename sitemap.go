@@ -0,0 +1,171 @@
+package soup
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/xml"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html/charset"
+)
+
+// SitemapEntry is an URL listed by a sitemap or a sitemap listed by a sitemap index
+type SitemapEntry struct {
+	Loc        string
+	LastMod    time.Time
+	ChangeFreq string
+	// Priority defaults to 0.5 if the sitemap doesn't state it
+	Priority float64
+}
+
+// Sitemap contains the URLs of a sitemap or the sitemaps of a sitemap index
+type Sitemap struct {
+	URLs     []SitemapEntry
+	Sitemaps []SitemapEntry
+}
+
+// sitemapXML is the XML structure shared by sitemaps and sitemap indexes
+type sitemapXML struct {
+	XMLName xml.Name
+	URLs    []sitemapEntryXML `xml:"url"`
+	Maps    []sitemapEntryXML `xml:"sitemap"`
+}
+
+// sitemapEntryXML is the XML structure of an url or sitemap element
+type sitemapEntryXML struct {
+	Loc        string `xml:"loc"`
+	LastMod    string `xml:"lastmod"`
+	ChangeFreq string `xml:"changefreq"`
+	Priority   string `xml:"priority"`
+}
+
+// the formats of W3C datetimes used by sitemaps, from the most to the least precise
+var w3cTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+	"2006-01",
+	"2006",
+}
+
+// parses a W3C datetime, returning the zero time if it can't be parsed
+func parseW3CTime(value string) time.Time {
+	value = strings.TrimSpace(value)
+	for _, layout := range w3cTimeLayouts {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return parsed
+		}
+	}
+	return time.Time{}
+}
+
+// decompresses gzipped content, returning other content as it is
+func gunzip(content []byte) ([]byte, error) {
+	if len(content) < 2 || content[0] != 0x1f || content[1] != 0x8b {
+		return content, nil
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return ioutil.ReadAll(reader)
+}
+
+// decodes the XML document into v, converting other charsets than UTF-8
+func decodeXML(content []byte, v interface{}) error {
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	decoder.CharsetReader = charset.NewReaderLabel
+	decoder.Strict = false
+	return decoder.Decode(v)
+}
+
+// ParseSitemap parses a sitemap or a sitemap index, which may be gzipped
+func ParseSitemap(content string) (*Sitemap, error) {
+	decompressed, err := gunzip([]byte(content))
+	if err != nil {
+		if debug {
+			panic("Unable to decompress the sitemap")
+		}
+		return nil, errors.New("unable to decompress the sitemap")
+	}
+	var document sitemapXML
+	if err := decodeXML(decompressed, &document); err != nil || (document.XMLName.Local != "urlset" && document.XMLName.Local != "sitemapindex") {
+		if debug {
+			panic("Unable to parse the sitemap")
+		}
+		return nil, errors.New("unable to parse the sitemap")
+	}
+	sitemap := &Sitemap{}
+	for _, entry := range document.URLs {
+		sitemap.URLs = append(sitemap.URLs, entry.entry())
+	}
+	for _, entry := range document.Maps {
+		sitemap.Sitemaps = append(sitemap.Sitemaps, entry.entry())
+	}
+	return sitemap, nil
+}
+
+// converts the XML entry
+func (e sitemapEntryXML) entry() SitemapEntry {
+	entry := SitemapEntry{
+		Loc:        strings.TrimSpace(e.Loc),
+		LastMod:    parseW3CTime(e.LastMod),
+		ChangeFreq: strings.ToLower(strings.TrimSpace(e.ChangeFreq)),
+		Priority:   0.5,
+	}
+	if priority, err := strconv.ParseFloat(strings.TrimSpace(e.Priority), 64); err == nil {
+		entry.Priority = priority
+	}
+	return entry
+}
+
+// FetchSitemap returns the URLs of the sitemap using the default HTTP client, see Session.FetchSitemap
+func FetchSitemap(url string) ([]SitemapEntry, error) {
+	return globalSession(&http.Client{}).FetchSitemap(url)
+}
+
+// FetchSitemap downloads the sitemap and returns its URLs.
+// The sitemaps listed by a sitemap index are downloaded as well, each of them once.
+func (s *Session) FetchSitemap(url string) ([]SitemapEntry, error) {
+	var urls []SitemapEntry
+	seen := make(map[string]bool)
+	var fetch func(url string) error
+	fetch = func(url string) error {
+		if seen[url] {
+			return nil
+		}
+		seen[url] = true
+		resp, err := s.Do("GET", url, nil)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode >= 400 {
+			if debug {
+				panic("Sitemap " + url + " returned status " + strconv.Itoa(resp.StatusCode))
+			}
+			return errors.New("sitemap " + url + " returned status " + strconv.Itoa(resp.StatusCode))
+		}
+		sitemap, err := ParseSitemap(resp.Body)
+		if err != nil {
+			return err
+		}
+		urls = append(urls, sitemap.URLs...)
+		for _, child := range sitemap.Sitemaps {
+			if err := fetch(child.Loc); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := fetch(url); err != nil {
+		return nil, err
+	}
+	return urls, nil
+}
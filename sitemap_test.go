@@ -0,0 +1,85 @@
+package soup
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseSitemap(t *testing.T) {
+	sitemap, err := ParseSitemap(`<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+	<url>
+		<loc> https://example.com/ </loc>
+		<lastmod>2024-05-01T10:30:00+02:00</lastmod>
+		<changefreq>Daily</changefreq>
+		<priority>1.0</priority>
+	</url>
+	<url><loc>https://example.com/about</loc><lastmod>2024-04-15</lastmod></url>
+</urlset>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []SitemapEntry{
+		{"https://example.com/", time.Date(2024, 5, 1, 8, 30, 0, 0, time.UTC), "daily", 1},
+		{"https://example.com/about", time.Date(2024, 4, 15, 0, 0, 0, 0, time.UTC), "", 0.5},
+	}
+	if len(sitemap.URLs) != 2 || len(sitemap.Sitemaps) != 0 {
+		t.Fatalf("Unexpected sitemap %+v", sitemap)
+	}
+	for position := range expected {
+		got := sitemap.URLs[position]
+		if got.Loc != expected[position].Loc || !got.LastMod.Equal(expected[position].LastMod) ||
+			got.ChangeFreq != expected[position].ChangeFreq || got.Priority != expected[position].Priority {
+			t.Errorf("Expected %+v, got %+v", expected[position], got)
+		}
+	}
+	if _, err := ParseSitemap(`<html><body>Not a sitemap</body></html>`); err == nil {
+		t.Errorf("Expected an error for a HTML page")
+	}
+}
+
+func TestFetchSitemap(t *testing.T) {
+	var gzipped bytes.Buffer
+	writer := gzip.NewWriter(&gzipped)
+	writer.Write([]byte(`<urlset><url><loc>https://example.com/b</loc></url></urlset>`))
+	writer.Close()
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/sitemap.xml":
+			w.Write([]byte(`<sitemapindex>
+				<sitemap><loc>` + ts.URL + `/a.xml</loc></sitemap>
+				<sitemap><loc>` + ts.URL + `/b.xml.gz</loc></sitemap>
+				<sitemap><loc>` + ts.URL + `/sitemap.xml</loc></sitemap>
+			</sitemapindex>`))
+		case "/a.xml":
+			w.Write([]byte(`<urlset><url><loc>https://example.com/a</loc></url></urlset>`))
+		case "/b.xml.gz":
+			w.Header().Set("Content-Type", "application/gzip")
+			w.Write(gzipped.Bytes())
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	urls, err := FetchSitemap(ts.URL + "/sitemap.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var locs []string
+	for _, entry := range urls {
+		locs = append(locs, entry.Loc)
+	}
+	if !reflect.DeepEqual(locs, []string{"https://example.com/a", "https://example.com/b"}) {
+		t.Errorf("Unexpected URLs %v", locs)
+	}
+	if _, err := NewSession().FetchSitemap(ts.URL + "/missing.xml"); err == nil {
+		t.Errorf("Expected an error for a missing sitemap")
+	}
+}
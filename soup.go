@@ -6,9 +6,11 @@ package soup
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
-	"io/ioutil"
+	"io"
 	"net/http"
+	neturl "net/url"
 	"regexp"
 	"strings"
 
@@ -21,6 +23,38 @@ type Root struct {
 	Pointer   *html.Node
 	NodeValue string
 	Error     error
+	// BaseURL is the URL the relative URLs of the document are resolved against, nil if it is unknown
+	BaseURL *neturl.URL
+}
+
+// Response contains the status, the headers, the final URL and the body of a HTTP response
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	URL        string
+	Body       string
+	// Cached is true if the response was taken from the cache of the session
+	Cached bool
+	// Proxy is the proxy the response was received through, empty if none of the session was used
+	Proxy string
+	// Redirects lists the redirects which led to the final URL, starting with the requested URL
+	Redirects []Redirect
+}
+
+// HTML parses the body of the response
+func (r *Response) HTML() Root {
+	return HTMLParseWithURL(r.Body, r.URL)
+}
+
+// JSON decodes the body of the response into the value
+func (r *Response) JSON(v interface{}) error {
+	if err := json.Unmarshal([]byte(r.Body), v); err != nil {
+		if debug {
+			panic("Unable to decode the response body as JSON")
+		}
+		return errors.New("unable to decode the response body as JSON")
+	}
+	return nil
 }
 
 var debug = false
@@ -31,6 +65,9 @@ var Headers = make(map[string]string)
 // Cookies contains all HTTP cookies to send
 var Cookies = make(map[string]string)
 
+// MaxBodySize limits the size of the decoded response bodies in bytes, 0 means unlimited
+var MaxBodySize int64
+
 // SetDebug sets the debug status
 // Setting this to true causes the panics to be thrown and logged onto the console.
 // Setting this to false causes the errors to be saved in the Error field in the returned struct.
@@ -49,41 +86,12 @@ func Cookie(n string, v string) {
 
 // GetWithClient returns the HTML returned by the url using a provided HTTP client
 func GetWithClient(url string, client *http.Client) (string, error) {
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		if debug {
-			panic("Couldn't perform GET request to " + url)
-		}
-		return "", errors.New("couldn't perform GET request to " + url)
-	}
-	// Set headers
-	for hName, hValue := range Headers {
-		req.Header.Set(hName, hValue)
-	}
-	// Set cookies
-	for cName, cValue := range Cookies {
-		req.AddCookie(&http.Cookie{
-			Name:  cName,
-			Value: cValue,
-		})
-	}
-	// Perform request
-	resp, err := client.Do(req)
-	if err != nil {
-		if debug {
-			panic("Couldn't perform GET request to " + url)
-		}
-		return "", errors.New("couldn't perform GET request to " + url)
-	}
-	defer resp.Body.Close()
-	bytes, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		if debug {
-			panic("Unable to read the response body")
-		}
-		return "", errors.New("unable to read the response body")
-	}
-	return string(bytes), nil
+	return globalSession(client).Get(url)
+}
+
+// returns a session sending the global Headers and Cookies with the provided HTTP client
+func globalSession(client *http.Client) *Session {
+	return &Session{Client: client, Headers: Headers, Cookies: Cookies, MaxBodySize: MaxBodySize}
 }
 
 // Get returns the HTML returned by the url in string using the default HTTP client
@@ -93,14 +101,46 @@ func Get(url string) (string, error) {
 	return GetWithClient(url, client)
 }
 
+// Post sends the values form-encoded to the url using the default HTTP client
+func Post(url string, values neturl.Values) (*Response, error) {
+	return globalSession(&http.Client{}).Post(url, values)
+}
+
+// PostJSON sends the value encoded as JSON to the url using the default HTTP client
+func PostJSON(url string, v interface{}) (*Response, error) {
+	return globalSession(&http.Client{}).PostJSON(url, v)
+}
+
+// PostMultipart sends the values and files as multipart/form-data to the url using the default HTTP client
+func PostMultipart(url string, values neturl.Values, files ...File) (*Response, error) {
+	return globalSession(&http.Client{}).PostMultipart(url, values, files...)
+}
+
+// Do sends a request with any method and an optional body to the url using the default HTTP client.
+// The content type of the body can be passed as well, otherwise it is taken from the Headers.
+func Do(method string, url string, body io.Reader, contentType ...string) (*Response, error) {
+	return DoWithClient(&http.Client{}, method, url, body, contentType...)
+}
+
+// DoWithClient sends a request with any method and an optional body to the url using a provided HTTP client
+func DoWithClient(client *http.Client, method string, url string, body io.Reader, contentType ...string) (*Response, error) {
+	return globalSession(client).Do(method, url, body, contentType...)
+}
+
 // HTMLParse parses the HTML returning a start pointer to the DOM
 func HTMLParse(s string) Root {
+	return HTMLParseWithURL(s, "")
+}
+
+// HTMLParseWithURL parses the HTML of the page at the URL returning a start pointer to the DOM,
+// whose relative URLs are resolved against the page URL and the <base href> of the document
+func HTMLParseWithURL(s string, pageURL string) Root {
 	r, err := html.Parse(strings.NewReader(s))
 	if err != nil {
 		if debug {
 			panic("Unable to parse the HTML")
 		}
-		return Root{nil, nil, "", errors.New("unable to parse the HTML")}
+		return Root{nil, nil, "", errors.New("unable to parse the HTML"), nil}
 	}
 	for r.Type != html.ElementNode {
 		switch r.Type {
@@ -112,7 +152,7 @@ func HTMLParse(s string) Root {
 			r = r.NextSibling
 		}
 	}
-	return Root{nil, r, r.Data, nil}
+	return Root{nil, r, r.Data, nil, documentBase(r, pageURL)}
 }
 
 // Find finds the first occurrence of the given tag name,
@@ -124,7 +164,7 @@ func (r Root) Find(args ...string) Root {
 		if debug {
 			panic("Element `" + args[0] + "` with attributes `" + strings.Join(args[1:], " ") + "` not found")
 		}
-		return Root{nil, nil, "", errors.New("element `" + args[0] + "` with attributes `" + strings.Join(args[1:], " ") + "` not found")}
+		return Root{nil, nil, "", errors.New("element `" + args[0] + "` with attributes `" + strings.Join(args[1:], " ") + "` not found"), nil}
 	}
 	return result
 }
@@ -137,7 +177,7 @@ func (r Root) FindStrict(args ...string) Root {
 		if debug {
 			panic("Element `" + args[0] + "` with attributes `" + strings.Join(args[1:], " ") + "` not found")
 		}
-		return Root{nil, nil, "", errors.New("element `" + args[0] + "` with attributes `" + strings.Join(args[1:], " ") + "` not found")}
+		return Root{nil, nil, "", errors.New("element `" + args[0] + "` with attributes `" + strings.Join(args[1:], " ") + "` not found"), nil}
 	}
 	return result
 }
@@ -224,9 +264,9 @@ func (r Root) FindNextSibling() Root {
 		if debug {
 			panic("No next sibling found")
 		}
-		return Root{nil, nil, "", errors.New("no next sibling found")}
+		return Root{nil, nil, "", errors.New("no next sibling found"), nil}
 	}
-	return Root{r.Parent, nextSibling, nextSibling.Data, nil}
+	return Root{r.Parent, nextSibling, nextSibling.Data, nil, r.BaseURL}
 }
 
 // FindPrevSibling finds the previous sibling of the pointer in the DOM
@@ -237,9 +277,9 @@ func (r Root) FindPrevSibling() Root {
 		if debug {
 			panic("No previous sibling found")
 		}
-		return Root{nil, nil, "", errors.New("no previous sibling found")}
+		return Root{nil, nil, "", errors.New("no previous sibling found"), nil}
 	}
-	return Root{r.Parent, prevSibling, prevSibling.Data, nil}
+	return Root{r.Parent, prevSibling, prevSibling.Data, nil, r.BaseURL}
 }
 
 // FindNextElementSibling finds the next element sibling of the pointer in the DOM
@@ -250,12 +290,12 @@ func (r Root) FindNextElementSibling() Root {
 		if debug {
 			panic("No next element sibling found")
 		}
-		return Root{nil, nil, "", errors.New("no next element sibling found")}
+		return Root{nil, nil, "", errors.New("no next element sibling found"), nil}
 	}
 	if nextSibling.Type == html.ElementNode {
-		return Root{r.Parent, nextSibling, nextSibling.Data, nil}
+		return Root{r.Parent, nextSibling, nextSibling.Data, nil, r.BaseURL}
 	}
-	p := Root{r.Parent, nextSibling, nextSibling.Data, nil}
+	p := Root{r.Parent, nextSibling, nextSibling.Data, nil, r.BaseURL}
 	return p.FindNextElementSibling()
 }
 
@@ -267,12 +307,12 @@ func (r Root) FindPrevElementSibling() Root {
 		if debug {
 			panic("No previous element sibling found")
 		}
-		return Root{nil, nil, "", errors.New("no previous element sibling found")}
+		return Root{nil, nil, "", errors.New("no previous element sibling found"), nil}
 	}
 	if prevSibling.Type == html.ElementNode {
-		return Root{r.Parent, prevSibling, prevSibling.Data, nil}
+		return Root{r.Parent, prevSibling, prevSibling.Data, nil, r.BaseURL}
 	}
-	p := Root{r.Parent, prevSibling, prevSibling.Data, nil}
+	p := Root{r.Parent, prevSibling, prevSibling.Data, nil, r.BaseURL}
 	return p.FindPrevElementSibling()
 }
 
@@ -283,7 +323,7 @@ func (r Root) Children(parameters ...bool) []Root {
 	var children []Root
 	for child != nil {
 		if len(parameters) == 1 && parameters[0] == true || child.Type == html.ElementNode {
-			children = append(children, Root{&r, child, child.Data, nil})
+			children = append(children, Root{&r, child, child.Data, nil, r.BaseURL})
 		}
 		child = child.NextSibling
 	}
@@ -297,7 +337,7 @@ func (r Root) Siblings(parameters ...bool) []Root {
 
 	for sibling := r.Pointer.NextSibling; sibling != nil; sibling = sibling.NextSibling {
 		if len(parameters) == 1 && parameters[0] == true || sibling.Type == html.ElementNode {
-			siblings = append(siblings, Root{r.Parent, sibling, sibling.Data, nil})
+			siblings = append(siblings, Root{r.Parent, sibling, sibling.Data, nil, r.BaseURL})
 		}
 	}
 
@@ -306,7 +346,7 @@ func (r Root) Siblings(parameters ...bool) []Root {
 
 // FindParent returns the parent element
 func (r Root) FindParent() Root {
-	return Root{r.Parent.Parent, r.Parent.Pointer, r.Parent.NodeValue, nil}
+	return Root{r.Parent.Parent, r.Parent.Pointer, r.Parent.NodeValue, nil, r.BaseURL}
 }
 
 // checks if the HTML Node has the given attribute
@@ -1,6 +1,10 @@
 package soup
 
 import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"reflect"
 	"strconv"
 	"strings"
@@ -212,3 +216,80 @@ func TestFullTextEmpty(t *testing.T) {
 		t.Errorf("Wrong text: %s", h1.FullText())
 	}
 }
+
+// echoes the method, path, content type and body of the request
+func echoHandler(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	w.Header().Set("X-Method", r.Method)
+	w.WriteHeader(http.StatusCreated)
+	w.Write([]byte(r.Method + " " + r.URL.Path + " " + strings.Split(r.Header.Get("Content-Type"), ";")[0] + " " + string(body)))
+}
+
+func TestPost(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(echoHandler))
+	defer ts.Close()
+
+	resp, err := Post(ts.URL+"/search", url.Values{"q": {"soup"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Body != "POST /search application/x-www-form-urlencoded q=soup" || resp.StatusCode != http.StatusCreated || resp.Header.Get("X-Method") != "POST" {
+		t.Errorf("Wrong response: %+v", resp)
+	}
+
+	resp, err = PostJSON(ts.URL+"/api", map[string]int{"page": 2})
+	if err != nil || resp.Body != `POST /api application/json {"page":2}` {
+		t.Errorf("Wrong response: %+v (%v)", resp, err)
+	}
+
+	resp, err = PostMultipart(ts.URL+"/upload", url.Values{"title": {"report"}}, File{"document", "report.txt", "text/plain", strings.NewReader("content")})
+	if err != nil || !strings.HasPrefix(resp.Body, "POST /upload multipart/form-data ") || !strings.Contains(resp.Body, "content") {
+		t.Errorf("Wrong response: %+v (%v)", resp, err)
+	}
+}
+
+func TestDo(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(echoHandler))
+	defer ts.Close()
+
+	resp, err := Do("PUT", ts.URL+"/item", strings.NewReader("<p>value</p>"), "text/html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.URL != ts.URL+"/item" || resp.HTML().Find("p").Text() != "value" {
+		t.Errorf("Wrong response: %+v", resp)
+	}
+
+	resp, err = Do("DELETE", ts.URL+"/item", nil)
+	if err != nil || resp.Body != "DELETE /item  " {
+		t.Errorf("Wrong response: %+v (%v)", resp, err)
+	}
+
+	var decoded map[string]string
+	resp = &Response{Body: `{"status":"ok"}`}
+	if err := resp.JSON(&decoded); err != nil || decoded["status"] != "ok" {
+		t.Errorf("Wrong JSON: %v (%v)", decoded, err)
+	}
+}
+
+// bareTransport answers every request without setting Response.Request, like some custom transports
+type bareTransport struct{}
+
+func (bareTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"text/html"}},
+		Body:       ioutil.NopCloser(strings.NewReader("<p>bare</p>")),
+	}, nil
+}
+
+func TestGetWithClientBareTransport(t *testing.T) {
+	actual, err := GetWithClient("http://example.com/page", &http.Client{Transport: bareTransport{}})
+	if err != nil || actual != "<p>bare</p>" {
+		t.Errorf("Wrong response: %s (%v)", actual, err)
+	}
+	resp, err := DoWithClient(&http.Client{Transport: bareTransport{}}, "GET", "http://example.com/page", nil)
+	if err != nil || resp.URL != "http://example.com/page" {
+		t.Errorf("Expected the requested URL as fallback, got %+v (%v)", resp, err)
+	}
+}
@@ -0,0 +1,196 @@
+package soup
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// maximum number of rows or columns a single cell may span
+const maxTableSpan = 1000
+
+// Table is a grid of cell texts with expanded rowspan and colspan,
+// split into the header rows and the body rows
+type Table struct {
+	Head  [][]string
+	Body  [][]string
+	Error error
+}
+
+// Table extracts the grid of the table element.
+// Cells spanning several rows or columns are repeated in every position they cover,
+// rows inside thead or consisting only of th cells are treated as header rows.
+func (r Root) Table() Table {
+	if r.Pointer == nil || r.Pointer.Type != html.ElementNode || r.Pointer.Data != "table" {
+		if debug {
+			panic("Element is not a table")
+		}
+		return Table{nil, nil, errors.New("element is not a table")}
+	}
+
+	type tableRow struct {
+		cells  []*html.Node
+		header bool
+	}
+	var rows []tableRow
+	var collect func(*html.Node, bool)
+	collect = func(parent *html.Node, inHead bool) {
+		for child := parent.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type != html.ElementNode {
+				continue
+			}
+			switch child.Data {
+			case "thead":
+				collect(child, true)
+			case "tbody", "tfoot":
+				collect(child, false)
+			case "tr":
+				row := tableRow{header: inHead}
+				onlyHeaderCells := true
+				for cell := child.FirstChild; cell != nil; cell = cell.NextSibling {
+					if cell.Type == html.ElementNode && (cell.Data == "td" || cell.Data == "th") {
+						row.cells = append(row.cells, cell)
+						onlyHeaderCells = onlyHeaderCells && cell.Data == "th"
+					}
+				}
+				row.header = row.header || (onlyHeaderCells && len(row.cells) > 0)
+				rows = append(rows, row)
+			}
+		}
+	}
+	collect(r.Pointer, false)
+
+	grid := make([][]string, len(rows))
+	filled := make([][]bool, len(rows))
+	width := 0
+	for y, row := range rows {
+		x := 0
+		for _, cell := range row.cells {
+			for x < len(filled[y]) && filled[y][x] {
+				x++
+			}
+			rowspan := tableSpan(cell, "rowspan")
+			colspan := tableSpan(cell, "colspan")
+			text := strings.TrimSpace(collapseWhitespace(rawText(cell)))
+			for dy := 0; dy < rowspan && y+dy < len(rows); dy++ {
+				for dx := 0; dx < colspan; dx++ {
+					for len(grid[y+dy]) <= x+dx {
+						grid[y+dy] = append(grid[y+dy], "")
+						filled[y+dy] = append(filled[y+dy], false)
+					}
+					grid[y+dy][x+dx] = text
+					filled[y+dy][x+dx] = true
+				}
+			}
+			x += colspan
+		}
+		for dy := y; dy < len(grid); dy++ {
+			if len(grid[dy]) > width {
+				width = len(grid[dy])
+			}
+		}
+	}
+
+	var table Table
+	for y, row := range rows {
+		for len(grid[y]) < width {
+			grid[y] = append(grid[y], "")
+		}
+		if row.header && len(table.Body) == 0 {
+			table.Head = append(table.Head, grid[y])
+		} else {
+			table.Body = append(table.Body, grid[y])
+		}
+	}
+	return table
+}
+
+// returns the span of the cell given in the attribute, defaults to 1
+func tableSpan(cell *html.Node, attribute string) int {
+	value, ok := attributeValue(cell, attribute)
+	if !ok {
+		return 1
+	}
+	span, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || span < 1 {
+		return 1
+	}
+	if span > maxTableSpan {
+		return maxTableSpan
+	}
+	return span
+}
+
+// Rows returns all rows below the header
+func (t Table) Rows() [][]string {
+	return t.Body
+}
+
+// Columns returns the name of each column.
+// Stacked header rows are joined with a space, without header rows the columns are numbered starting with 1.
+func (t Table) Columns() []string {
+	width := 0
+	if len(t.Head) > 0 {
+		width = len(t.Head[0])
+	} else if len(t.Body) > 0 {
+		width = len(t.Body[0])
+	}
+	columns := make([]string, width)
+	for x := range columns {
+		var parts []string
+		for y := range t.Head {
+			value := t.Head[y][x]
+			if value != "" && (len(parts) == 0 || parts[len(parts)-1] != value) {
+				parts = append(parts, value)
+			}
+		}
+		columns[x] = strings.Join(parts, " ")
+		if len(t.Head) == 0 {
+			columns[x] = strconv.Itoa(x + 1)
+		}
+	}
+	return columns
+}
+
+// Records returns every row as a map of column name to cell text.
+// Duplicate column names get their column number appended.
+func (t Table) Records() []map[string]string {
+	columns := t.Columns()
+	seen := make(map[string]bool)
+	for x, column := range columns {
+		if seen[column] {
+			columns[x] = column + " " + strconv.Itoa(x+1)
+		}
+		seen[columns[x]] = true
+	}
+	records := make([]map[string]string, 0, len(t.Body))
+	for _, row := range t.Body {
+		record := make(map[string]string, len(columns))
+		for x, column := range columns {
+			record[column] = row[x]
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// WriteCSV writes the column names, if the table has a header, followed by all rows as CSV
+func (t Table) WriteCSV(w io.Writer) error {
+	if t.Error != nil {
+		return t.Error
+	}
+	writer := csv.NewWriter(w)
+	if len(t.Head) > 0 {
+		if err := writer.Write(t.Columns()); err != nil {
+			return err
+		}
+	}
+	if err := writer.WriteAll(t.Body); err != nil {
+		return err
+	}
+	return writer.Error()
+}
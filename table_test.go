@@ -0,0 +1,75 @@
+package soup
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+const tableHTML = `
+<table id="spans">
+  <thead>
+    <tr><th rowspan="2">Name</th><th colspan="2">Score</th></tr>
+    <tr><th>First</th><th>Second</th></tr>
+  </thead>
+  <tbody>
+    <tr><td rowspan="2">Alice</td><td>1</td><td>2</td></tr>
+    <tr><td colspan="2">3, "4"</td></tr>
+    <tr><td>Bob</td><td>5</td></tr>
+  </tbody>
+</table>
+<table id="plain">
+  <tr><th>Key</th><th>Key</th></tr>
+  <tr><td>a</td><td>b</td></tr>
+</table>
+<table id="headless">
+  <tr><td>x</td><td>y</td></tr>
+</table>
+`
+
+var tableDoc = HTMLParse(tableHTML)
+
+func TestTableRows(t *testing.T) {
+	table := tableDoc.Find("table", "id", "spans").Table()
+	expectedHead := [][]string{{"Name", "Score", "Score"}, {"Name", "First", "Second"}}
+	if !reflect.DeepEqual(table.Head, expectedHead) {
+		t.Errorf("Wrong header rows: %v", table.Head)
+	}
+	expectedRows := [][]string{{"Alice", "1", "2"}, {"Alice", `3, "4"`, `3, "4"`}, {"Bob", "5", ""}}
+	if !reflect.DeepEqual(table.Rows(), expectedRows) {
+		t.Errorf("Wrong rows: %v", table.Rows())
+	}
+	if !reflect.DeepEqual(table.Columns(), []string{"Name", "Score First", "Score Second"}) {
+		t.Errorf("Wrong columns: %v", table.Columns())
+	}
+}
+
+func TestTableRecords(t *testing.T) {
+	records := tableDoc.Find("table", "id", "plain").Table().Records()
+	expected := []map[string]string{{"Key": "a", "Key 2": "b"}}
+	if !reflect.DeepEqual(records, expected) {
+		t.Errorf("Wrong records: %v", records)
+	}
+	records = tableDoc.Find("table", "id", "headless").Table().Records()
+	expected = []map[string]string{{"1": "x", "2": "y"}}
+	if !reflect.DeepEqual(records, expected) {
+		t.Errorf("Wrong records: %v", records)
+	}
+}
+
+func TestTableWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := tableDoc.Find("table", "id", "spans").Table().WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	expected := "Name,Score First,Score Second\nAlice,1,2\nAlice,\"3, \"\"4\"\"\",\"3, \"\"4\"\"\"\nBob,5,\n"
+	if buf.String() != expected {
+		t.Errorf("Wrong CSV:\n%s", buf.String())
+	}
+}
+
+func TestTableNotATable(t *testing.T) {
+	if tableDoc.Find("td").Table().Error == nil {
+		t.Errorf("Table() on a non-table element should return an error")
+	}
+}
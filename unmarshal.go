@@ -0,0 +1,282 @@
+package soup
+
+import (
+	"encoding"
+	"errors"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FieldError describes why a single struct field could not be populated
+type FieldError struct {
+	Field    string
+	Selector string
+	Err      error
+}
+
+func (e *FieldError) Error() string {
+	return "field `" + e.Field + "` (selector `" + e.Selector + "`): " + e.Err.Error()
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// UnmarshalError contains the errors of all fields which could not be populated
+type UnmarshalError struct {
+	Errors []*FieldError
+}
+
+func (e *UnmarshalError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for position, fieldError := range e.Errors {
+		messages[position] = fieldError.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+func (e *UnmarshalError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for position, fieldError := range e.Errors {
+		errs[position] = fieldError
+	}
+	return errs
+}
+
+// ErrNoMatch is reported for fields whose selector does not match any element
+var ErrNoMatch = errors.New("no element matches")
+
+var (
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	timeType            = reflect.TypeOf(time.Time{})
+	numberPattern       = regexp.MustCompile(`[-+]?[0-9][0-9,]*(\.[0-9]+)?([eE][-+]?[0-9]+)?`)
+	timeLayouts         = []string{time.RFC3339Nano, time.RFC3339, time.RFC1123Z, time.RFC1123, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02"}
+)
+
+// Unmarshal populates the struct pointed to by v with the content of the elements
+// selected by the `soup` tags of its fields, e.g. `soup:"span.price"` or `soup:"a.link,attr=href"`.
+// An empty selector refers to the element itself.
+//
+// The text of an element (or of the attribute given by attr=name) is converted into strings,
+// integers, floats, bools, time.Time (using the layout given by layout=..., which has to be the last option)
+// and types implementing encoding.TextUnmarshaler. Struct fields are populated from the first
+// matching element, slices from all matching elements. A missing element is an error unless
+// the field is a pointer or a slice, which are left empty.
+// All field errors are collected and returned together as an *UnmarshalError.
+func Unmarshal(root Root, v interface{}) error {
+	value := reflect.ValueOf(v)
+	if value.Kind() != reflect.Ptr || value.IsNil() || value.Elem().Kind() != reflect.Struct {
+		if debug {
+			panic("Unmarshal needs a non-nil pointer to a struct")
+		}
+		return errors.New("unmarshal needs a non-nil pointer to a struct")
+	}
+	if root.Pointer == nil {
+		if debug {
+			panic("Unable to unmarshal an empty element")
+		}
+		return errors.New("unable to unmarshal an empty element")
+	}
+	var fieldErrors []*FieldError
+	unmarshalStruct(root, value.Elem(), "", &fieldErrors)
+	if len(fieldErrors) > 0 {
+		err := &UnmarshalError{fieldErrors}
+		if debug {
+			panic(err.Error())
+		}
+		return err
+	}
+	return nil
+}
+
+// fieldOptions are the parsed options of a `soup` tag
+type fieldOptions struct {
+	selector  string
+	attribute string
+	layout    string
+}
+
+// parses a tag like `a.link,attr=href`
+func parseFieldTag(tag string) fieldOptions {
+	parts := strings.Split(tag, ",")
+	options := fieldOptions{selector: strings.TrimSpace(parts[0])}
+	for position := 1; position < len(parts); position++ {
+		option := strings.TrimSpace(parts[position])
+		switch {
+		case strings.HasPrefix(option, "attr="):
+			options.attribute = strings.TrimPrefix(option, "attr=")
+		case strings.HasPrefix(option, "layout="):
+			options.layout = strings.TrimPrefix(strings.TrimLeft(strings.Join(parts[position:], ","), " "), "layout=")
+			return options
+		}
+	}
+	return options
+}
+
+// populates all tagged fields of the struct
+func unmarshalStruct(root Root, value reflect.Value, path string, fieldErrors *[]*FieldError) {
+	structType := value.Type()
+	for position := 0; position < structType.NumField(); position++ {
+		field := structType.Field(position)
+		tag, ok := field.Tag.Lookup("soup")
+		if !ok || tag == "-" || field.PkgPath != "" {
+			continue
+		}
+		options := parseFieldTag(tag)
+		fieldPath := field.Name
+		if path != "" {
+			fieldPath = path + "." + field.Name
+		}
+		addError := func(err error) {
+			*fieldErrors = append(*fieldErrors, &FieldError{fieldPath, options.selector, err})
+		}
+
+		var sel selector
+		if options.selector != "" {
+			compiled, err := compileSelector(options.selector)
+			if err != nil {
+				addError(err)
+				continue
+			}
+			sel = compiled
+		}
+
+		fieldValue := value.Field(position)
+		if fieldValue.Kind() == reflect.Slice && fieldValue.Type().Elem().Kind() != reflect.Uint8 {
+			var elements []Root
+			if sel == nil {
+				elements = []Root{root}
+			} else {
+				elements = root.selectAll(sel)
+			}
+			slice := reflect.MakeSlice(fieldValue.Type(), len(elements), len(elements))
+			for index, element := range elements {
+				unmarshalValue(element, slice.Index(index), options, fieldPath+"["+strconv.Itoa(index)+"]", options.selector, fieldErrors)
+			}
+			fieldValue.Set(slice)
+			continue
+		}
+
+		element := root
+		if sel != nil {
+			found, ok := root.selectOnce(sel)
+			if !ok {
+				if fieldValue.Kind() != reflect.Ptr {
+					addError(ErrNoMatch)
+				}
+				continue
+			}
+			element = found
+		}
+		unmarshalValue(element, fieldValue, options, fieldPath, options.selector, fieldErrors)
+	}
+}
+
+// populates a single value from the element
+func unmarshalValue(element Root, value reflect.Value, options fieldOptions, path string, selectorString string, fieldErrors *[]*FieldError) {
+	if value.Kind() == reflect.Ptr {
+		target := reflect.New(value.Type().Elem())
+		errorCount := len(*fieldErrors)
+		unmarshalValue(element, target.Elem(), options, path, selectorString, fieldErrors)
+		if len(*fieldErrors) == errorCount {
+			value.Set(target)
+		}
+		return
+	}
+	if value.Kind() == reflect.Struct && value.Type() != timeType && !reflect.PtrTo(value.Type()).Implements(textUnmarshalerType) {
+		unmarshalStruct(element, value, path, fieldErrors)
+		return
+	}
+	text, ok := elementValue(element, options.attribute, value.Type() == timeType)
+	if !ok {
+		*fieldErrors = append(*fieldErrors, &FieldError{path, selectorString, errors.New("attribute `" + options.attribute + "` not found")})
+		return
+	}
+	if err := convertText(text, value, options); err != nil {
+		*fieldErrors = append(*fieldErrors, &FieldError{path, selectorString, err})
+	}
+}
+
+// returns the text of the element or the value of the given attribute
+func elementValue(element Root, attribute string, isTime bool) (string, bool) {
+	if attribute != "" {
+		value, ok := attributeValue(element.Pointer, attribute)
+		return strings.TrimSpace(value), ok
+	}
+	if isTime {
+		if value, ok := attributeValue(element.Pointer, "datetime"); ok {
+			return strings.TrimSpace(value), true
+		}
+	}
+	return strings.TrimSpace(collapseWhitespace(rawText(element.Pointer))), true
+}
+
+// converts the text into the type of the value
+func convertText(text string, value reflect.Value, options fieldOptions) error {
+	if value.Type() == timeType {
+		layouts := timeLayouts
+		if options.layout != "" {
+			layouts = []string{options.layout}
+		}
+		for _, layout := range layouts {
+			if parsed, err := time.Parse(layout, text); err == nil {
+				value.Set(reflect.ValueOf(parsed))
+				return nil
+			}
+		}
+		return errors.New("unable to parse `" + text + "` as time")
+	}
+	if value.CanAddr() && value.Addr().Type().Implements(textUnmarshalerType) {
+		return value.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(text))
+	}
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(text)
+	case reflect.Slice:
+		value.SetBytes([]byte(text))
+	case reflect.Bool:
+		switch strings.ToLower(text) {
+		case "":
+			// a boolean attribute like `checked` is present without a value
+			value.SetBool(options.attribute != "")
+		case "1", "t", "true", "yes", "on":
+			value.SetBool(true)
+		case "0", "f", "false", "no", "off":
+			value.SetBool(false)
+		default:
+			return errors.New("unable to parse `" + text + "` as bool")
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		parsed, err := strconv.ParseInt(numberText(text), 10, value.Type().Bits())
+		if err != nil {
+			return errors.New("unable to parse `" + text + "` as integer")
+		}
+		value.SetInt(parsed)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		parsed, err := strconv.ParseUint(numberText(text), 10, value.Type().Bits())
+		if err != nil {
+			return errors.New("unable to parse `" + text + "` as unsigned integer")
+		}
+		value.SetUint(parsed)
+	case reflect.Float32, reflect.Float64:
+		parsed, err := strconv.ParseFloat(numberText(text), value.Type().Bits())
+		if err != nil {
+			return errors.New("unable to parse `" + text + "` as float")
+		}
+		value.SetFloat(parsed)
+	default:
+		return errors.New("unsupported type " + value.Type().String())
+	}
+	return nil
+}
+
+// extracts the first number from a text like `$1,299.00`, dropping thousands separators
+func numberText(text string) string {
+	if _, err := strconv.ParseFloat(text, 64); err == nil {
+		return text
+	}
+	return strings.Replace(numberPattern.FindString(text), ",", "", -1)
+}
@@ -0,0 +1,133 @@
+package soup
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+const productsHTML = `
+<html>
+  <body>
+    <h1 class="shop">Example Shop</h1>
+    <div class="product" data-id="17">
+      <h2><a class="link" href="/products/17">Coffee</a></h2>
+      <span class="price">$1,299.50</span>
+      <span class="stock">3 left</span>
+      <input type="checkbox" name="gift" checked>
+      <time datetime="2020-02-03T10:00:00Z">3rd of February</time>
+      <span class="sku">ab-17</span>
+      <ul class="tags"><li>hot</li><li>black</li></ul>
+    </div>
+    <div class="product" data-id="18">
+      <h2><a class="link" href="/products/18">Tea</a></h2>
+      <span class="price">4.20</span>
+      <span class="stock">none</span>
+      <span class="rating">4.5</span>
+      <time>2020-02-04</time>
+      <span class="sku">cd-18</span>
+    </div>
+  </body>
+</html>
+`
+
+// sku is a custom type implementing encoding.TextUnmarshaler
+type sku struct {
+	Prefix string
+	Number string
+}
+
+func (s *sku) UnmarshalText(text []byte) error {
+	parts := strings.SplitN(string(text), "-", 2)
+	if len(parts) != 2 {
+		return errors.New("invalid sku")
+	}
+	s.Prefix, s.Number = parts[0], parts[1]
+	return nil
+}
+
+type product struct {
+	ID      int       `soup:",attr=data-id"`
+	Name    string    `soup:"a.link"`
+	Link    string    `soup:"h2 > a,attr=href"`
+	Price   float64   `soup:"span.price"`
+	Gift    *bool     `soup:"input[name=gift],attr=checked"`
+	Added   time.Time `soup:"time"`
+	SKU     sku       `soup:".sku"`
+	Tags    []string  `soup:"ul.tags li"`
+	Rating  *float64  `soup:"span.rating"`
+	Ignored string
+}
+
+type shop struct {
+	Name     string    `soup:"h1.shop"`
+	Products []product `soup:"div.product"`
+	First    *product  `soup:"div.product"`
+}
+
+func TestUnmarshal(t *testing.T) {
+	var result shop
+	err := Unmarshal(HTMLParse(productsHTML), &result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Name != "Example Shop" || len(result.Products) != 2 || result.First == nil {
+		t.Fatalf("Wrong result: %+v", result)
+	}
+	coffee := result.Products[0]
+	if coffee.ID != 17 || coffee.Name != "Coffee" || coffee.Link != "/products/17" || coffee.Price != 1299.5 {
+		t.Errorf("Wrong product: %+v", coffee)
+	}
+	if coffee.Gift == nil || !*coffee.Gift || !coffee.Added.Equal(time.Date(2020, 2, 3, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("Wrong product: %+v", coffee)
+	}
+	if coffee.SKU.Prefix != "ab" || coffee.SKU.Number != "17" || len(coffee.Tags) != 2 || coffee.Tags[1] != "black" {
+		t.Errorf("Wrong product: %+v", coffee)
+	}
+	if coffee.Rating != nil {
+		t.Errorf("Missing optional field should stay nil")
+	}
+	tea := result.Products[1]
+	if tea.Rating == nil || *tea.Rating != 4.5 || tea.Gift != nil || len(tea.Tags) != 0 {
+		t.Errorf("Wrong product: %+v", tea)
+	}
+	if result.First.Name != "Coffee" {
+		t.Errorf("Wrong first product: %+v", result.First)
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	var result struct {
+		Stock   []int  `soup:"span.stock"`
+		Missing string `soup:"div.missing"`
+		Title   string `soup:"h1[,attr=id"`
+	}
+	err := Unmarshal(HTMLParse(productsHTML), &result)
+	var unmarshalError *UnmarshalError
+	if !errors.As(err, &unmarshalError) {
+		t.Fatalf("Expected an UnmarshalError, got %v", err)
+	}
+	// "none" can't be converted, the missing element and the broken selector are reported as well
+	if len(unmarshalError.Errors) != 3 {
+		t.Fatalf("Expected 3 field errors, got %v", err)
+	}
+	if unmarshalError.Errors[0].Field != "Stock[1]" || result.Stock[0] != 3 {
+		t.Errorf("Wrong field error: %v", unmarshalError.Errors[0])
+	}
+	if !errors.Is(err, ErrNoMatch) {
+		t.Errorf("Missing element should be reported as ErrNoMatch")
+	}
+}
+
+func TestUnmarshalTimeLayout(t *testing.T) {
+	var result struct {
+		Date time.Time `soup:"p,layout=Jan 2, 2006"`
+	}
+	if err := Unmarshal(HTMLParse("<p>Feb 3, 2020</p>"), &result); err != nil {
+		t.Fatal(err)
+	}
+	if !result.Date.Equal(time.Date(2020, 2, 3, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Wrong date: %v", result.Date)
+	}
+}
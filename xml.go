@@ -0,0 +1,117 @@
+package soup
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+)
+
+// XMLParse parses the XML returning a start pointer to the DOM, see XMLParseReader
+func XMLParse(s string) Root {
+	return XMLParseReader(strings.NewReader(s))
+}
+
+// XMLParseReader parses the XML read from the reader returning a start pointer to the DOM of its root element.
+// Unlike HTMLParse it keeps the case and the namespace prefixes of element and attribute names, like `soap:Body`,
+// and doesn't add any elements, so Find, FindAll, Text and Attributes work on the document as it is.
+// The namespace URIs of the elements are stored in the Namespace field of their nodes,
+// CDATA sections become text nodes, processing instructions and directives raw nodes.
+func XMLParseReader(r io.Reader) Root {
+	doc, err := parseXML(r)
+	if err != nil {
+		if debug {
+			panic("Unable to parse the XML")
+		}
+		return Root{nil, nil, "", errors.New("unable to parse the XML"), nil}
+	}
+	for n := doc.FirstChild; n != nil; n = n.NextSibling {
+		if n.Type == html.ElementNode {
+			return Root{nil, n, n.Data, nil, nil}
+		}
+	}
+	if debug {
+		panic("No root element found in the XML")
+	}
+	return Root{nil, nil, "", errors.New("no root element found in the XML"), nil}
+}
+
+// returns the qualified name with its namespace prefix
+func qualifiedName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return name.Space + ":" + name.Local
+}
+
+// builds the node tree of the XML document
+func parseXML(r io.Reader) (*html.Node, error) {
+	decoder := xml.NewDecoder(r)
+	decoder.CharsetReader = charset.NewReaderLabel
+	decoder.Entity = xml.HTMLEntity
+	doc := &html.Node{Type: html.DocumentNode}
+	current := doc
+	// namespaces maps the prefixes declared by the open elements to their URIs, "" being the default namespace
+	namespaces := []map[string]string{{"xml": "http://www.w3.org/XML/1998/namespace"}}
+	lookup := func(prefix string) string {
+		for position := len(namespaces) - 1; position >= 0; position-- {
+			if uri, ok := namespaces[position][prefix]; ok {
+				return uri
+			}
+		}
+		return ""
+	}
+	for {
+		// RawToken keeps the prefixes instead of replacing them by the namespace URIs
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			declared := make(map[string]string)
+			n := &html.Node{Type: html.ElementNode, Data: qualifiedName(token.Name)}
+			for _, attribute := range token.Attr {
+				switch {
+				case attribute.Name.Space == "" && attribute.Name.Local == "xmlns":
+					declared[""] = attribute.Value
+				case attribute.Name.Space == "xmlns":
+					declared[attribute.Name.Local] = attribute.Value
+				}
+				n.Attr = append(n.Attr, html.Attribute{Key: qualifiedName(attribute.Name), Val: attribute.Value})
+			}
+			namespaces = append(namespaces, declared)
+			n.Namespace = lookup(token.Name.Space)
+			current.AppendChild(n)
+			current = n
+		case xml.EndElement:
+			if current == doc || current.Data != qualifiedName(token.Name) {
+				return nil, errors.New("unexpected end element " + qualifiedName(token.Name))
+			}
+			namespaces = namespaces[:len(namespaces)-1]
+			current = current.Parent
+		case xml.CharData:
+			if current == doc {
+				// whitespace outside of the root element isn't part of the document
+				continue
+			}
+			current.AppendChild(&html.Node{Type: html.TextNode, Data: string(token)})
+		case xml.Comment:
+			current.AppendChild(&html.Node{Type: html.CommentNode, Data: string(token)})
+		case xml.ProcInst:
+			current.AppendChild(&html.Node{Type: html.RawNode, Data: "<?" + token.Target + " " + string(token.Inst) + "?>"})
+		case xml.Directive:
+			current.AppendChild(&html.Node{Type: html.RawNode, Data: "<!" + string(token) + ">"})
+		}
+	}
+	if current != doc {
+		return nil, errors.New("unclosed element " + current.Data)
+	}
+	return doc, nil
+}
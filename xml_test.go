@@ -0,0 +1,79 @@
+package soup
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+const soapResponse = `<?xml version="1.0" encoding="UTF-8"?>
+<!-- generated -->
+<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/" xmlns="urn:stock">
+	<soap:Body>
+		<GetPriceResponse Currency="EUR">
+			<Price>34.5</Price>
+			<Note><![CDATA[<b>not</b> markup]]></Note>
+			<?render fast?>
+			<Item ID="1">First</Item>
+			<Item ID="2">Second</Item>
+		</GetPriceResponse>
+	</soap:Body>
+</soap:Envelope>`
+
+func TestXMLParse(t *testing.T) {
+	doc := XMLParse(soapResponse)
+	if doc.Error != nil {
+		t.Fatal(doc.Error)
+	}
+	if doc.NodeValue != "soap:Envelope" || doc.Pointer.Namespace != "http://schemas.xmlsoap.org/soap/envelope/" {
+		t.Errorf("Unexpected root element %s in %s", doc.NodeValue, doc.Pointer.Namespace)
+	}
+	response := doc.Find("soap:Body").Find("GetPriceResponse")
+	if response.Error != nil || response.Pointer.Namespace != "urn:stock" {
+		t.Fatalf("Element with preserved case not found: %v", response.Error)
+	}
+	if !reflect.DeepEqual(response.Attributes(), map[string]string{"Currency": "EUR"}) {
+		t.Errorf("Unexpected attributes %v", response.Attributes())
+	}
+	if price := response.Find("Price").Text(); price != "34.5" {
+		t.Errorf("Expected price 34.5, got %q", price)
+	}
+	if note := response.Find("Note").Text(); note != "<b>not</b> markup" {
+		t.Errorf("CDATA should be kept as text, got %q", note)
+	}
+	if second := doc.Find("Item", "ID", "2").Text(); second != "Second" {
+		t.Errorf("Expected the second item, got %q", second)
+	}
+	if items := doc.FindAll("Item"); len(items) != 2 {
+		t.Errorf("Expected 2 items, got %d", len(items))
+	}
+	if doc.Find("price").Error == nil {
+		t.Errorf("Element names should be case sensitive")
+	}
+	var instruction string
+	for n := response.Pointer.FirstChild; n != nil; n = n.NextSibling {
+		if n.Type == html.RawNode {
+			instruction = n.Data
+		}
+	}
+	if instruction != "<?render fast?>" {
+		t.Errorf("Processing instruction not kept, got %q", instruction)
+	}
+	if doc.Pointer.Parent.FirstChild.Type != html.RawNode || doc.Pointer.PrevSibling.Type != html.CommentNode {
+		t.Errorf("Prolog not kept before the root element")
+	}
+}
+
+func TestXMLParseReader(t *testing.T) {
+	doc := XMLParseReader(strings.NewReader(`<?xml version="1.0" encoding="ISO-8859-1"?><Name>Caf` + "\xe9" + ` &amp; Bar&nbsp;</Name>`))
+	if doc.Error != nil || doc.Text() != "Caf\u00e9 & Bar\u00a0" {
+		t.Errorf("Unexpected text %q (%v)", doc.Text(), doc.Error)
+	}
+	for _, invalid := range []string{"<a><b></a>", "<a>", "plain text"} {
+		if XMLParse(invalid).Error == nil {
+			t.Errorf("Expected an error for %q", invalid)
+		}
+	}
+}